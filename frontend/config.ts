@@ -13,6 +13,17 @@ export const getWebSocketURL = () => {
   }
 };
 
+// Multiplexed endpoint carrying every machine over one socket, see ws-proxy/fleet.go
+export const getFleetURL = () => {
+  if (import.meta.env.MODE === 'production') {
+    return `ws://${window.location.host}/ws/fleet`;
+  }
+  return 'ws://localhost:3001/fleet';
+};
+
+// WebSocket subprotocol naming the version of the /fleet protocol
+export const fleetProtocol = 'machine-map.v1';
+
 export const getMapsApiKey = () => {
   return import.meta.env.VITE_GOOGLE_MAPS_API_KEY || '';
 }
\ No newline at end of file
@@ -1,7 +1,7 @@
 // App.tsx
-import React, { useEffect, useState } from 'react';
+import React, { useEffect, useRef, useState } from 'react';
 import { GoogleMap, LoadScript, Marker, InfoWindow } from '@react-google-maps/api';
-import { getWebSocketURL, getMapsApiKey } from '../config'
+import { getFleetURL, fleetProtocol, getMapsApiKey } from '../config'
 import './App.css';
 
 const mapsApiKey = getMapsApiKey()
@@ -12,13 +12,76 @@ interface GPS {
   alt: number;
 }
 
+// Mirrors the MachineStatus enum in machine_stream.proto
+const machineStatusLabels = ['Idle', 'Moving', 'Out of Fuel', 'Returning', 'Fault'];
+
+// Zero values are omitted from the JSON, so numeric telemetry fields may be missing
 interface Machine {
   id: number;
   location: GPS;
   fuel_level: number;
   is_paused: boolean;
+  timestamp?: { seconds?: number; nanos?: number };
+  heading?: number;
+  speed?: number;
+  odometer?: number;
+  status?: number;
+  mission?: { leg_index?: number; leg_count?: number; percent_complete?: number };
+}
+
+// Sent by the WebSocket proxy when a command or stream fails
+interface ErrorFrame {
+  type: 'error';
+  request_id?: string;
+  command?: string;
+  code: string;
+  reason?: string;
+  message: string;
+  id?: number;
+}
+
+// Frames of the /fleet protocol, each tagged with its type
+interface TelemetryFrame {
+  type: 'telemetry';
+  machines?: Machine[];
+  removed?: number[];
+}
+
+interface AckFrame {
+  type: 'ack';
+  request_id?: string;
+  command: string;
+  machine?: Machine;
+  ids?: number[];
+  all?: boolean;
+}
+
+interface EventFrame {
+  type: 'event';
+  event: { id: string; machine_id: number; [detail: string]: unknown };
 }
 
+// First frame on every socket. The token and the last seq received resume the session after a reconnect
+interface SessionFrame {
+  type: 'session';
+  token: string;
+  seq: number; // frames sent so far
+  resumed: boolean;
+  missed?: ServerFrame[]; // sent while disconnected, oldest first
+  resynced?: boolean;
+}
+
+// Every frame but the session frame is numbered by seq
+type ServerFrame = SessionFrame | ((TelemetryFrame | AckFrame | ErrorFrame | EventFrame) & { seq?: number });
+
+const reconnectDelayMs = 1000;
+
+// Request ids are a per-tab random prefix plus a counter. crypto.randomUUID only exists over HTTPS
+// or on localhost, and the dashboard is served over plain HTTP
+const requestIdPrefix = `${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 10)}`;
+let requestCount = 0;
+const nextRequestId = () => `${requestIdPrefix}-${++requestCount}`;
+
 const mapContainerStyle = {
   width: '100%',
   height: '50vh',
@@ -32,112 +95,124 @@ const center = {
 function App() {
   const [machines, setMachines] = useState<Map<number, Machine>>(new Map()); // machine.id: data
   const [selectedMachine, setSelectedMachine] = useState<Machine | null>(null);
-  const [socketConnections, setSocketConnections] = useState<Map<number,WebSocket>>(new Map()); // machine.id: socketConnection
-
-  // // Initialize machine on page load
-  // useEffect(() => {
-  //   const ws = new WebSocket('ws://localhost:3001/machine');
-    
-  //   ws.onopen = () => {
-  //     console.log('WebSocket connection established');
-  //   };
-    
-  //   ws.onmessage = (event) => {
-  //     const machine = JSON.parse(event.data) as Machine;
-  //     console.log('Machine message received:', machine);
-      
-  //     setMachines((prev) => {
-  //       const updated = new Map(prev);
-  //       updated.set(machine.id, machine);
-  //       return updated;
-  //     });
-  //   };
-    
-  //   ws.onerror = (error) => {
-  //     console.error('WebSocket error:', error);
-  //   };
-    
-  //   ws.onclose = () => {
-  //     console.log('WebSocket connection closed');
-  //   };
-    
-  //   setSocket(ws);
-    
-  //   // Clean up WebSocket on unmount
-  //   return () => {
-  //     ws.close();
-  //   };
-  // }, []);
-
-  // Function to pause/unpause a machine
-  const togglePause = (machine: Machine) => {
-    // console.log(`Socket Connections in togglePause for ${machine.id}`, socketConnections)
-    const socket = socketConnections.get(machine.id)
+  const socketRef = useRef<WebSocket | null>(null); // one socket carries every machine
 
-    if (!socket || socket.readyState !== WebSocket.OPEN) {
-      console.error(`WebSocket not connected for machine ${machine.id}`);
-      return;
-    }
-    
-    try {
-      const command = {
-        type: machine.is_paused ? 'unpause' : 'pause',
-        id: machine.id
-      };
-      
-      console.log(`Sending ${command.type} command for machine ${command.id}`);
-      socket.send(JSON.stringify(command));
-      
-      // Note: The actual state update will come through the WebSocket stream
-    } catch (error) {
-      console.error('Error sending command via WebSocket:', error);
-    }
+  const upsertMachines = (updated: Machine[]) => {
+    setMachines((prev) => {
+      const next = new Map(prev);
+      updated.forEach((machine) => next.set(machine.id, machine));
+      return next;
+    });
   };
 
-  // Function to add a new machine by creating a new websocket connection
-  const addMachine = () => {
-    const newSocket = new WebSocket(getWebSocketURL());
+  const dropMachines = (ids: number[]) => {
+    setMachines((prev) => {
+      const next = new Map(prev);
+      ids.forEach((id) => next.delete(id));
+      return next;
+    });
+    setSelectedMachine((selected) => (selected && ids.includes(selected.id) ? null : selected));
+  };
+
+  // Connect once and watch the whole fleet, reconnecting to the same session when the socket drops
+  useEffect(() => {
+    let token = '';
+    let lastSeq = 0;
+    let stopped = false;
+    let retry: ReturnType<typeof setTimeout> | undefined;
 
-    newSocket.onopen = () => {
-      console.log(`New machine connection established.`);
+    const handleFrame = (frame: ServerFrame) => {
+      if (frame.type !== 'session') {
+        // Acks may overtake telemetry, so this is the highest seq rather than the latest
+        lastSeq = Math.max(lastSeq, frame.seq ?? 0);
+      }
+      switch (frame.type) {
+        case 'session':
+          if (!frame.resumed) {
+            // A new session, either the first or the old one expired
+            token = frame.token;
+            lastSeq = frame.seq;
+            socketRef.current?.send(JSON.stringify({ type: 'subscribe', ids: 'all' }));
+          }
+          (frame.missed ?? []).forEach(handleFrame);
+          break;
+        case 'telemetry':
+          upsertMachines(frame.machines ?? []);
+          dropMachines(frame.removed ?? []);
+          break;
+        case 'ack':
+          if (frame.command === 'delete' && frame.machine) {
+            dropMachines([frame.machine.id]);
+          } else if (frame.machine) {
+            upsertMachines([frame.machine]);
+          }
+          break;
+        case 'error':
+          console.error(`${frame.command || 'request'} failed for machine ${frame.id ?? 'unknown'}: ${frame.code} ${frame.message} (request ${frame.request_id ?? 'unknown'})`);
+          break;
+        case 'event':
+          console.log(`Event for machine ${frame.event.machine_id}:`, frame.event);
+          break;
+      }
     };
-    
-    newSocket.onmessage = (event) => {
-      const newMachine = JSON.parse(event.data) as Machine;
-      // console.log('New machine data received from gRPC Server to WebSocket Proxy:', newMachine);
 
-      // Update machines state
-      setMachines((prev) => {
-        const updatedMachines = new Map(prev); // machine.id: machine
-        updatedMachines.set(newMachine.id,newMachine)
-        return updatedMachines
-      });
+    const connect = () => {
+      const url = token ? `${getFleetURL()}?session=${token}&seq=${lastSeq}` : getFleetURL();
+      const socket = new WebSocket(url, fleetProtocol);
+      socketRef.current = socket;
 
-      // Update socketConnections state
-      setSocketConnections((prev) => {
-        const updatedSocketConnections = new Map(prev)
+      socket.onopen = () => {
+        console.log('Fleet connection established');
+      };
 
-        if (!updatedSocketConnections.has(newMachine.id)){
-          updatedSocketConnections.set(newMachine.id,newSocket)
+      socket.onmessage = (event) => {
+        handleFrame(JSON.parse(event.data) as ServerFrame);
+      };
 
+      socket.onerror = (error) => {
+        console.error('Fleet connection error:', error);
+      };
+
+      socket.onclose = () => {
+        if (stopped) {
+          return;
         }
-        return updatedSocketConnections
-      });
-    };
-    
-    newSocket.onerror = (error) => {
-      console.error('Error in new machine connection:', error);
+        console.log(`Fleet connection closed, reconnecting in ${reconnectDelayMs} ms`);
+        retry = setTimeout(connect, reconnectDelayMs);
+      };
     };
-    
-    newSocket.onclose = () => {
-      console.log('Machine connection closed');
+    connect();
+
+    return () => {
+      stopped = true;
+      clearTimeout(retry);
+      socketRef.current?.close();
     };
+  }, []);
+
+  // Each command carries a request_id, echoed in its ack or error. A retry reusing it is not applied twice
+  const send = (command: object) => {
+    const socket = socketRef.current;
+    if (!socket || socket.readyState !== WebSocket.OPEN) {
+      console.error('Fleet connection is not open');
+      return;
+    }
+    socket.send(JSON.stringify({ ...command, request_id: nextRequestId() }));
   };
 
-  const removeMachine = (machineId: number) => {
-    console.log(`Cannot remove machine ${machineId} - not supported by current gRPC service`);
-    alert("Machine removal isn't supported by the current service implementation");
+  // Function to pause/unpause a machine. The new state arrives as an ack
+  const togglePause = (machine: Machine) => {
+    send({ type: machine.is_paused ? 'unpause' : 'pause', id: machine.id });
+  };
 
+  // Function to add a new machine, which the server starts streaming once created
+  const addMachine = () => {
+    send({ type: 'create' });
+  };
+
+  // Function to delete a machine on the server
+  const removeMachine = (machineId: number) => {
+    send({ type: 'delete', id: machineId });
   };
 
   return (
@@ -182,7 +257,15 @@ function App() {
                 <div className="info-window">
                   <h3>Machine #{selectedMachine.id}</h3>
                   <p>Fuel Level: {selectedMachine.fuel_level.toFixed(2)}%</p>
-                  <p>Status: {selectedMachine.is_paused ? 'Paused' : 'Active'}</p>
+                  <p>Status: {machineStatusLabels[selectedMachine.status ?? 0]}</p>
+                  <p>Speed: {(selectedMachine.speed ?? 0).toFixed(2)} m/s, Heading: {(selectedMachine.heading ?? 0).toFixed(0)}°</p>
+                  <p>Odometer: {(selectedMachine.odometer ?? 0).toFixed(1)} m</p>
+                  {selectedMachine.mission && (
+                    <p>
+                      Mission: leg {(selectedMachine.mission.leg_index ?? 0) + 1} of {selectedMachine.mission.leg_count},{' '}
+                      {(selectedMachine.mission.percent_complete ?? 0).toFixed(0)}% complete
+                    </p>
+                  )}
                   <p>
                     Location: {selectedMachine.location.lat.toFixed(6)}, {selectedMachine.location.lon.toFixed(6)}
                   </p>
@@ -227,6 +310,9 @@ function App() {
                   <div className="fuel-level">
                     Fuel: {machine.fuel_level.toFixed(1)}%
                   </div>
+                  <div className="machine-status">
+                    {machineStatusLabels[machine.status ?? 0]}
+                  </div>
                   <div className="button-container">
                     <button
                       className={machine.is_paused ? "unpause-btn" : "pause-btn"}
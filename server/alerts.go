@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultLowFuelPercent = 20
+	maxClearedAlerts      = 1000 // cleared alerts kept for ListAlerts
+)
+
+// AlertRules are the thresholds alerts are raised at. A zero threshold disables its rule
+type AlertRules struct {
+	FuelBelow        float32       // percent
+	PausedLongerThan time.Duration // of simulated time
+	NoMovementTicks  int
+	MinAltitude      float32 // meters, the band is only checked when MaxAltitude is above MinAltitude
+	MaxAltitude      float32
+}
+
+var alertRules = []pb.AlertRule{
+	pb.AlertRule_LOW_FUEL,
+	pb.AlertRule_PAUSED_TOO_LONG,
+	pb.AlertRule_NO_MOVEMENT,
+	pb.AlertRule_ALTITUDE_OUT_OF_BAND,
+}
+
+func (r AlertRules) enabled() bool {
+	return r.FuelBelow > 0 || r.PausedLongerThan > 0 || r.NoMovementTicks > 0 || r.MaxAltitude > r.MinAltitude
+}
+
+// check returns the reading a rule watches and whether it matches. Caller must hold machine.mutex
+func (r AlertRules) check(rule pb.AlertRule, m *Machine, now time.Time) (float64, bool) {
+	switch rule {
+	case pb.AlertRule_LOW_FUEL:
+		return float64(m.FuelLevel), r.FuelBelow > 0 && m.FuelLevel < r.FuelBelow
+	case pb.AlertRule_PAUSED_TOO_LONG:
+		if m.pausedSince.IsZero() {
+			return 0, false
+		}
+		paused := now.Sub(m.pausedSince)
+		return paused.Seconds(), r.PausedLongerThan > 0 && paused > r.PausedLongerThan
+	case pb.AlertRule_NO_MOVEMENT:
+		return float64(m.stillTicks), r.NoMovementTicks > 0 && m.stillTicks >= r.NoMovementTicks
+	case pb.AlertRule_ALTITUDE_OUT_OF_BAND:
+		alt := m.Location.Alt
+		return float64(alt), r.MaxAltitude > r.MinAltitude && (alt < r.MinAltitude || alt > r.MaxAltitude)
+	}
+	return 0, false
+}
+
+func (r AlertRules) describe(rule pb.AlertRule, value float64) string {
+	switch rule {
+	case pb.AlertRule_LOW_FUEL:
+		return fmt.Sprintf("fuel at %.1f%%, below %g%%", value, r.FuelBelow)
+	case pb.AlertRule_PAUSED_TOO_LONG:
+		return fmt.Sprintf("paused for %s, longer than %s", time.Duration(value*float64(time.Second)).Round(time.Second), r.PausedLongerThan)
+	case pb.AlertRule_NO_MOVEMENT:
+		return fmt.Sprintf("no movement for %.0f ticks", value)
+	case pb.AlertRule_ALTITUDE_OUT_OF_BAND:
+		return fmt.Sprintf("altitude %.1f m outside %g to %g m", value, r.MinAltitude, r.MaxAltitude)
+	}
+	return rule.String()
+}
+
+// Alerts holds the active alerts of every machine and the most recently cleared ones
+type Alerts struct {
+	rules   AlertRules
+	mu      sync.Mutex
+	lastID  uint64
+	active  map[uint64]*pb.Alert
+	cleared []*pb.Alert // oldest first
+}
+
+func newAlerts(rules AlertRules) *Alerts {
+	return &Alerts{rules: rules, active: make(map[uint64]*pb.Alert)}
+}
+
+// WithAlertRules replaces the default thresholds, which only alert on low fuel
+func WithAlertRules(rules AlertRules) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.alerts.rules = rules
+	}
+}
+
+// raise records a new active alert, returning a copy to publish
+func (a *Alerts) raise(machineID uint32, rule pb.AlertRule, value float64, now time.Time) *pb.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastID++
+	alert := &pb.Alert{
+		Id:        a.lastID,
+		MachineId: machineID,
+		Rule:      rule,
+		Message:   a.rules.describe(rule, value),
+		Value:     value,
+		RaisedAt:  timestamppb.New(now),
+	}
+	a.active[alert.Id] = alert
+	return proto.Clone(alert).(*pb.Alert)
+}
+
+// clear moves an active alert to the cleared history, returning a copy to publish
+func (a *Alerts) clear(id uint64, now time.Time) *pb.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	alert, ok := a.active[id]
+	if !ok {
+		return nil
+	}
+	delete(a.active, id)
+	alert.ClearedAt = timestamppb.New(now)
+
+	a.cleared = append(a.cleared, alert)
+	if len(a.cleared) > maxClearedAlerts {
+		a.cleared = slices.Delete(a.cleared, 0, len(a.cleared)-maxClearedAlerts)
+	}
+	return proto.Clone(alert).(*pb.Alert)
+}
+
+func (a *Alerts) list(machineID uint32, includeCleared bool) []*pb.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var alerts []*pb.Alert
+	add := func(alert *pb.Alert) {
+		if machineID == 0 || alert.MachineId == machineID {
+			alerts = append(alerts, proto.Clone(alert).(*pb.Alert))
+		}
+	}
+	for _, alert := range a.active {
+		add(alert)
+	}
+	if includeCleared {
+		for _, alert := range a.cleared {
+			add(alert)
+		}
+	}
+	slices.SortFunc(alerts, func(x, y *pb.Alert) int { return cmp.Compare(x.Id, y.Id) })
+	return alerts
+}
+
+// evaluateAlerts checks a machine against every rule after it advanced from previous, raising
+// alerts for rules that started matching and clearing those that stopped. Caller must hold machine.mutex
+func (mm *MachineManager) evaluateAlerts(machine *Machine, previous *pb.GPS, now time.Time) {
+	if machine.Status == pb.MachineStatus_IDLE {
+		if machine.pausedSince.IsZero() {
+			machine.pausedSince = now
+		}
+	} else {
+		machine.pausedSince = time.Time{}
+	}
+	if !machine.isPaused() && previous.Lat == machine.Location.Lat && previous.Lon == machine.Location.Lon && previous.Alt == machine.Location.Alt {
+		machine.stillTicks++
+	} else {
+		machine.stillTicks = 0
+	}
+
+	rules := mm.alerts.rules
+	if machine.removed || (!rules.enabled() && len(machine.alerts) == 0) {
+		return
+	}
+	for _, rule := range alertRules {
+		value, matched := rules.check(rule, machine, now)
+		id, active := machine.alerts[rule]
+		switch {
+		case matched && !active:
+			alert := mm.alerts.raise(machine.ID, rule, value, now)
+			machine.alerts[rule] = alert.Id
+			mm.publishEvent(machine.ID, now, &pb.Event{Detail: &pb.Event_Alert{Alert: alert}})
+		case !matched && active:
+			delete(machine.alerts, rule)
+			mm.clearAlert(machine.ID, id, now)
+		}
+	}
+}
+
+func (mm *MachineManager) clearAlert(machineID uint32, id uint64, now time.Time) {
+	if alert := mm.alerts.clear(id, now); alert != nil {
+		mm.publishEvent(machineID, now, &pb.Event{Detail: &pb.Event_Alert{Alert: alert}})
+	}
+}
+
+// clearAlerts clears every active alert of a deleted machine
+func (mm *MachineManager) clearAlerts(machine *Machine) {
+	now := mm.clock.Now()
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+
+	for rule, id := range machine.alerts {
+		delete(machine.alerts, rule)
+		mm.clearAlert(machine.ID, id, now)
+	}
+}
+
+// gRPC method to list active alerts, and optionally recently cleared ones
+func (mm *MachineManager) ListAlerts(ctx context.Context, req *pb.ListAlertsRequest) (*pb.ListAlertsResponse, error) {
+	if req.MachineId != 0 && !req.IncludeCleared {
+		if _, err := mm.lookupMachine(req.MachineId); err != nil {
+			return nil, err
+		}
+	}
+	return &pb.ListAlertsResponse{Alerts: mm.alerts.list(req.MachineId, req.IncludeCleared)}, nil
+}
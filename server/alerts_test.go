@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+)
+
+// setupAlertServer starts a paused simulation alerting on rules, with one machine
+func setupAlertServer(t *testing.T, rules AlertRules) (*MachineManager, *pb.Machine, *Subscription[*pb.Event]) {
+	t.Helper()
+	mm := NewMachineManager(WithAlertRules(rules))
+	t.Cleanup(mm.Close)
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+	machine, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+	sub, _ := mm.events.Subscribe(SubscribeOptions{})
+	return mm, machine, sub
+}
+
+func alerts(sub *Subscription[*pb.Event]) []*pb.Alert {
+	var alerts []*pb.Alert
+	for _, event := range sub.Drain() {
+		if alert := event.GetAlert(); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func TestLowFuelAlertRaisedOnceAndClearedByRefuel(t *testing.T) {
+	mm, machine, sub := setupAlertServer(t, AlertRules{FuelBelow: 99.85})
+	ctx := context.Background()
+	mm.UnPause(ctx, &pb.Machine{Id: machine.Id})
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := alerts(sub); len(got) != 0 {
+		t.Fatalf("got %v at 99.9%% fuel, want no alert", got)
+	}
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 3})
+	raised := alerts(sub)
+	if len(raised) != 1 || raised[0].Rule != pb.AlertRule_LOW_FUEL || raised[0].MachineId != machine.Id || raised[0].ClearedAt != nil {
+		t.Fatalf("got %v, want one low fuel alert", raised)
+	}
+	if list, _ := mm.ListAlerts(ctx, &pb.ListAlertsRequest{}); len(list.Alerts) != 1 || list.Alerts[0].Id != raised[0].Id {
+		t.Errorf("got active alerts %v, want the raised alert", list.Alerts)
+	}
+
+	mm.Refuel(ctx, &pb.RefuelRequest{Id: machine.Id})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	cleared := alerts(sub)
+	if len(cleared) != 1 || cleared[0].Id != raised[0].Id || cleared[0].ClearedAt == nil {
+		t.Fatalf("got %v after refuelling, want the alert cleared", cleared)
+	}
+	if list, _ := mm.ListAlerts(ctx, &pb.ListAlertsRequest{}); len(list.Alerts) != 0 {
+		t.Errorf("got active alerts %v, want none", list.Alerts)
+	}
+	if list, _ := mm.ListAlerts(ctx, &pb.ListAlertsRequest{MachineId: machine.Id, IncludeCleared: true}); len(list.Alerts) != 1 || list.Alerts[0].ClearedAt == nil {
+		t.Errorf("got %v including cleared, want the cleared alert", list.Alerts)
+	}
+}
+
+func TestPausedTooLongAlert(t *testing.T) {
+	mm, machine, sub := setupAlertServer(t, AlertRules{PausedLongerThan: 2 * time.Second})
+	ctx := context.Background()
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 3})
+	if got := alerts(sub); len(got) != 0 {
+		t.Fatalf("got %v after 2s paused, want no alert", got)
+	}
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := alerts(sub); len(got) != 1 || got[0].Rule != pb.AlertRule_PAUSED_TOO_LONG || got[0].Value != 3 {
+		t.Fatalf("got %v, want a paused alert after 3s", got)
+	}
+
+	mm.UnPause(ctx, &pb.Machine{Id: machine.Id})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := alerts(sub); len(got) != 1 || got[0].ClearedAt == nil {
+		t.Errorf("got %v after unpausing, want the alert cleared", got)
+	}
+}
+
+func TestNoMovementAlert(t *testing.T) {
+	mm, created, sub := setupAlertServer(t, AlertRules{NoMovementTicks: 3})
+	mm.UnPause(context.Background(), &pb.Machine{Id: created.Id})
+	machine, _ := mm.getMachine(created.Id)
+
+	// Evaluate a moving machine that does not move, as if its motion had stalled
+	machine.mutex.Lock()
+	still := &pb.GPS{Lat: machine.Location.Lat, Lon: machine.Location.Lon, Alt: machine.Location.Alt}
+	for range 3 {
+		mm.evaluateAlerts(machine, still, time.Now())
+	}
+	moved := &pb.GPS{Lat: still.Lat + 0.0001, Lon: still.Lon, Alt: still.Alt}
+	mm.evaluateAlerts(machine, moved, time.Now())
+	machine.mutex.Unlock()
+
+	got := alerts(sub)
+	if len(got) != 2 || got[0].Rule != pb.AlertRule_NO_MOVEMENT || got[0].Value != 3 || got[1].ClearedAt == nil {
+		t.Errorf("got %v, want an alert raised after 3 still ticks and cleared once moving", got)
+	}
+}
+
+func TestAltitudeAlertClearedWhenMachineDeleted(t *testing.T) {
+	mm, machine, sub := setupAlertServer(t, AlertRules{MinAltitude: 0, MaxAltitude: 5})
+	ctx := context.Background()
+
+	// Machines start 10m or more above sea level
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := alerts(sub); len(got) != 1 || got[0].Rule != pb.AlertRule_ALTITUDE_OUT_OF_BAND || got[0].Message == "" {
+		t.Fatalf("got %v, want an altitude alert", got)
+	}
+
+	mm.DeleteMachine(ctx, &pb.Machine{Id: machine.Id})
+	if got := alerts(sub); len(got) != 1 || got[0].ClearedAt == nil {
+		t.Errorf("got %v after deleting the machine, want the alert cleared", got)
+	}
+	if list, _ := mm.ListAlerts(ctx, &pb.ListAlertsRequest{}); len(list.Alerts) != 0 {
+		t.Errorf("got active alerts %v, want none", list.Alerts)
+	}
+}
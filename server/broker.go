@@ -0,0 +1,377 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultMaxLag = 256
+	maxMaxLag     = 100_000
+)
+
+// Broker fans machine state changes, or events, out to streams. Every subscriber has its own bounded
+// queue and slow-consumer policy, so a slow reader never holds up the scheduler or other readers
+type Broker[E any] struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription[E]]struct{}
+	policy      pb.SlowConsumerPolicy // used by subscribers that ask for SERVER_DEFAULT
+	maxLag      int                   // used by subscribers that ask for 0
+	machineOf   func(E) uint32        // machine an item is about, for filtering and coalescing
+	coalesce    func(queued, next E) (E, bool)
+}
+
+// subscriberIDs numbers subscribers across every broker, so stats never share an id
+var subscriberIDs atomic.Uint64
+
+// SubscribeOptions describes a subscriber and how to treat it when it falls behind
+type SubscribeOptions struct {
+	Method  string // RPC the subscriber serves, for stats
+	Machine uint32 // only items about this machine, or every machine when 0
+	Policy  pb.SlowConsumerPolicy
+	MaxLag  uint32
+}
+
+// Subscription queues the items about the machines it is interested in until its stream sends them
+type Subscription[E any] struct {
+	id           uint64
+	method       string
+	machine      uint32
+	policy       pb.SlowConsumerPolicy
+	maxLag       int
+	subscribedAt time.Time
+	machineOf    func(E) uint32
+	coalesce     func(queued, next E) (E, bool)
+
+	mu        sync.Mutex // serializes publishers and the reader so overflow handling is atomic
+	queue     []E
+	base      int            // position of queue[0] among every item ever queued
+	latest    map[uint32]int // COALESCE_LATEST only, position of the latest queued item of each machine
+	peakLag   int
+	lagged    bool
+	ready     chan struct{} // signalled when items are queued
+	laggedOut chan struct{} // closed when a DISCONNECT subscriber exceeds its max lag
+
+	sent      atomic.Uint64
+	dropped   atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+// NewBroker creates a broker for items about the machine returned by machineOf. coalesce merges
+// a newer item into the queued item of the same machine for COALESCE_LATEST subscribers, or
+// reports false to queue it separately. Without coalesce COALESCE_LATEST behaves like DROP_OLDEST
+func NewBroker[E any](machineOf func(E) uint32, coalesce func(queued, next E) (E, bool)) *Broker[E] {
+	return &Broker[E]{
+		subscribers: make(map[*Subscription[E]]struct{}),
+		policy:      pb.SlowConsumerPolicy_DROP_OLDEST,
+		maxLag:      defaultMaxLag,
+		machineOf:   machineOf,
+		coalesce:    coalesce,
+	}
+}
+
+// NewFleetBroker creates a broker for machine state changes. Coalesced updates keep the type of the
+// queued event, so an ADDED machine is still reported as added
+func NewFleetBroker() *Broker[*pb.FleetEvent] {
+	return NewBroker(
+		func(event *pb.FleetEvent) uint32 { return event.Machine.Id },
+		func(queued, next *pb.FleetEvent) (*pb.FleetEvent, bool) {
+			if next.Type != pb.FleetEvent_UPDATED || queued.Type == pb.FleetEvent_REMOVED {
+				return nil, false
+			}
+			return &pb.FleetEvent{Type: queued.Type, Machine: next.Machine}, true
+		},
+	)
+}
+
+// NewEventBroker creates a broker for machine events. Events are never coalesced, each one matters
+func NewEventBroker() *Broker[*pb.Event] {
+	return NewBroker(func(event *pb.Event) uint32 { return event.MachineId }, nil)
+}
+
+// WithSlowConsumerPolicy sets the policy and max lag for streams that do not choose their own
+func WithSlowConsumerPolicy(policy pb.SlowConsumerPolicy, maxLag int) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.broker.setDefaults(policy, maxLag)
+		mm.events.setDefaults(policy, maxLag)
+	}
+}
+
+func (b *Broker[E]) setDefaults(policy pb.SlowConsumerPolicy, maxLag int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.policy, b.maxLag = policy, maxLag
+}
+
+// parseSlowConsumerPolicy accepts policy names such as "drop-oldest" or "COALESCE_LATEST"
+func parseSlowConsumerPolicy(name string) (pb.SlowConsumerPolicy, error) {
+	value, ok := pb.SlowConsumerPolicy_value[strings.ToUpper(strings.ReplaceAll(name, "-", "_"))]
+	if !ok || value == int32(pb.SlowConsumerPolicy_SERVER_DEFAULT) {
+		return 0, fmt.Errorf("unknown slow consumer policy %q", name)
+	}
+	return pb.SlowConsumerPolicy(value), nil
+}
+
+// Subscribe registers a subscriber, filling in the server defaults for its policy and max lag
+func (b *Broker[E]) Subscribe(opts SubscribeOptions) (*Subscription[E], error) {
+	if _, ok := pb.SlowConsumerPolicy_name[int32(opts.Policy)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown slow consumer policy %d", opts.Policy)
+	}
+	if opts.MaxLag > maxMaxLag {
+		return nil, status.Errorf(codes.InvalidArgument, "max_lag must be at most %d", maxMaxLag)
+	}
+
+	sub := &Subscription[E]{
+		id:           subscriberIDs.Add(1),
+		method:       opts.Method,
+		machine:      opts.Machine,
+		policy:       opts.Policy,
+		maxLag:       int(opts.MaxLag),
+		subscribedAt: time.Now(),
+		machineOf:    b.machineOf,
+		coalesce:     b.coalesce,
+		ready:        make(chan struct{}, 1),
+		laggedOut:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sub.policy == pb.SlowConsumerPolicy_SERVER_DEFAULT {
+		sub.policy = b.policy
+	}
+	if sub.maxLag == 0 {
+		sub.maxLag = b.maxLag
+	}
+	if sub.policy == pb.SlowConsumerPolicy_COALESCE_LATEST && sub.coalesce != nil {
+		sub.latest = make(map[uint32]int)
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+func (b *Broker[E]) Unsubscribe(sub *Subscription[E]) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+}
+
+// Publish hands an item to every interested subscriber without blocking
+func (b *Broker[E]) Publish(item E) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	machine := b.machineOf(item)
+	for sub := range b.subscribers {
+		if sub.machine == 0 || sub.machine == machine {
+			sub.offer(item)
+		}
+	}
+}
+
+// Stats reports the delivery counters of every subscriber, ordered by id
+func (b *Broker[E]) Stats() []*pb.SubscriberStats {
+	b.mu.RLock()
+	stats := make([]*pb.SubscriberStats, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		stats = append(stats, sub.stats())
+	}
+	b.mu.RUnlock()
+
+	slices.SortFunc(stats, func(a, b *pb.SubscriberStats) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return stats
+}
+
+// Ready is signalled when items are waiting to be taken with Drain
+func (s *Subscription[E]) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Lagged is closed when the subscriber fell too far behind and its stream should end
+func (s *Subscription[E]) Lagged() <-chan struct{} {
+	return s.laggedOut
+}
+
+// Drain takes every queued item, oldest first
+func (s *Subscription[E]) Drain() []E {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.queue
+	s.queue = nil
+	s.base += len(items)
+	clear(s.latest)
+	s.sent.Add(uint64(len(items)))
+	return items
+}
+
+// laggedError is returned to a stream ended by the DISCONNECT policy
+func (s *Subscription[E]) laggedError() error {
+	return status.Errorf(codes.ResourceExhausted, "stream fell more than %d updates behind", s.maxLag)
+}
+
+// offer queues an item, applying the slow-consumer policy when the queue is full
+func (s *Subscription[E]) offer(item E) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lagged {
+		return
+	}
+
+	machine := s.machineOf(item)
+	if s.latest != nil {
+		if position, ok := s.latest[machine]; ok {
+			if merged, ok := s.coalesce(s.queue[position-s.base], item); ok {
+				s.queue[position-s.base] = merged
+				s.coalesced.Add(1)
+				return
+			}
+		}
+	}
+
+	if len(s.queue) >= s.maxLag {
+		if s.policy == pb.SlowConsumerPolicy_DISCONNECT {
+			s.lagged = true
+			s.queue = nil
+			close(s.laggedOut)
+			return
+		}
+
+		oldest := s.machineOf(s.queue[0])
+		if s.latest[oldest] == s.base {
+			delete(s.latest, oldest)
+		}
+		clear(s.queue[:1]) // release the dropped item
+		s.queue = s.queue[1:]
+		s.base++
+		s.dropped.Add(1)
+	}
+	s.queue = append(s.queue, item)
+	s.peakLag = max(s.peakLag, len(s.queue))
+	if s.latest != nil {
+		s.latest[machine] = s.base + len(s.queue) - 1
+	}
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription[E]) stats() *pb.SubscriberStats {
+	s.mu.Lock()
+	lag, peakLag := len(s.queue), s.peakLag
+	s.mu.Unlock()
+
+	return &pb.SubscriberStats{
+		Id:                 s.id,
+		Method:             s.method,
+		MachineId:          s.machine,
+		SlowConsumerPolicy: s.policy,
+		MaxLag:             uint32(s.maxLag),
+		Lag:                uint32(lag),
+		PeakLag:            uint32(peakLag),
+		Sent:               s.sent.Load(),
+		Dropped:            s.dropped.Load(),
+		Coalesced:          s.coalesced.Load(),
+		SubscribedAt:       timestamppb.New(s.subscribedAt),
+	}
+}
+
+// gRPC method to inspect the lag and drop counters of every open stream
+func (mm *MachineManager) ListSubscribers(ctx context.Context, req *pb.ListSubscribersRequest) (*pb.ListSubscribersResponse, error) {
+	subscribers := append(mm.broker.Stats(), mm.events.Stats()...)
+	slices.SortFunc(subscribers, func(a, b *pb.SubscriberStats) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return &pb.ListSubscribersResponse{Subscribers: subscribers}, nil
+}
+
+// forwardMachine sends a machine's changes as they are published, until the client disconnects, the
+// machine is removed or the subscriber falls too far behind. Changes queue up while Send blocks,
+// the subscriber's slow-consumer policy bounds the backlog
+func forwardMachine(stream pb.MachineMap_MachineStreamServer, sub *Subscription[*pb.FleetEvent]) error {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Lagged():
+			log.Printf("Disconnecting slow MachineStream subscriber %d for machine %d", sub.id, sub.machine)
+			return sub.laggedError()
+		case <-sub.Ready():
+			for _, event := range sub.Drain() {
+				if event.Type == pb.FleetEvent_REMOVED {
+					return nil
+				}
+				if err := stream.Send(event.Machine); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// forwardFleet sends fleet events as they are published, batching those that queued up while
+// sending, until the client disconnects or the subscriber falls too far behind
+func forwardFleet(stream pb.MachineMap_WatchFleetServer, sub *Subscription[*pb.FleetEvent]) error {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Lagged():
+			log.Printf("Disconnecting slow WatchFleet subscriber %d", sub.id)
+			return sub.laggedError()
+		case <-sub.Ready():
+			events := sub.Drain()
+			if len(events) == 0 {
+				continue
+			}
+			if err := stream.Send(&pb.FleetUpdate{Events: events}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// notify samples a machine and publishes the sample as an event of the given type. Updates are
+// only published when the state differs from what subscribers last saw, ignoring the sample
+// timestamp. Returns the sample, or nil if the machine has since been removed
+func (mm *MachineManager) notify(machine *Machine, eventType pb.FleetEvent_Type) *pb.Machine {
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+
+	if machine.removed {
+		return nil
+	}
+	sample := machine.toProto()
+	if eventType == pb.FleetEvent_UPDATED && machine.published != nil && !stateChanged(machine.published, sample) {
+		return sample
+	}
+	if eventType == pb.FleetEvent_REMOVED {
+		machine.removed = true
+	}
+	machine.published = sample
+
+	// Publish under the machine lock so subscribers see each machine's events in order
+	mm.broker.Publish(&pb.FleetEvent{Type: eventType, Machine: sample})
+	return sample
+}
+
+// notifyChange publishes a machine's state if it changed. Returns nil if the machine has been removed
+func (mm *MachineManager) notifyChange(machine *Machine) *pb.Machine {
+	return mm.notify(machine, pb.FleetEvent_UPDATED)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func subscribe(t *testing.T, broker *Broker[*pb.FleetEvent], opts SubscribeOptions) *Subscription[*pb.FleetEvent] {
+	t.Helper()
+	sub, err := broker.Subscribe(opts)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	return sub
+}
+
+func updated(id uint32, fuel float32) *pb.FleetEvent {
+	return &pb.FleetEvent{Type: pb.FleetEvent_UPDATED, Machine: &pb.Machine{Id: id, FuelLevel: fuel}}
+}
+
+func TestBrokerFiltersByMachine(t *testing.T) {
+	broker := NewFleetBroker()
+	one := subscribe(t, broker, SubscribeOptions{Machine: 1})
+	all := subscribe(t, broker, SubscribeOptions{})
+
+	broker.Publish(updated(1, 0))
+	broker.Publish(updated(2, 0))
+
+	if got, want := len(one.Drain()), 1; got != want {
+		t.Errorf("machine subscriber got %d events, want %d", got, want)
+	}
+	if got, want := len(all.Drain()), 2; got != want {
+		t.Errorf("fleet subscriber got %d events, want %d", got, want)
+	}
+
+	broker.Unsubscribe(all)
+	broker.Publish(updated(2, 0))
+	if events := all.Drain(); len(events) != 0 {
+		t.Errorf("unsubscribed subscriber still received %d events", len(events))
+	}
+}
+
+func TestBrokerDefaults(t *testing.T) {
+	broker := NewFleetBroker()
+	sub := subscribe(t, broker, SubscribeOptions{})
+	if sub.policy != pb.SlowConsumerPolicy_DROP_OLDEST || sub.maxLag != defaultMaxLag {
+		t.Errorf("got policy %v and max lag %d, want the server defaults", sub.policy, sub.maxLag)
+	}
+
+	if _, err := broker.Subscribe(SubscribeOptions{Policy: 42}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown policy: got %v, want InvalidArgument", err)
+	}
+	if _, err := broker.Subscribe(SubscribeOptions{MaxLag: maxMaxLag + 1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("max lag too large: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestBrokerDropsOldestWhenFull(t *testing.T) {
+	broker := NewFleetBroker()
+	sub := subscribe(t, broker, SubscribeOptions{Policy: pb.SlowConsumerPolicy_DROP_OLDEST, MaxLag: 2})
+
+	for id := uint32(1); id <= 3; id++ {
+		broker.Publish(updated(id, 0))
+	}
+
+	stats := broker.Stats()[0]
+	if stats.Dropped != 1 || stats.Lag != 2 || stats.PeakLag != 2 {
+		t.Errorf("got dropped %d, lag %d and peak lag %d, want 1, 2 and 2", stats.Dropped, stats.Lag, stats.PeakLag)
+	}
+	events := sub.Drain()
+	if len(events) != 2 || events[0].Machine.Id != 2 {
+		t.Errorf("got %v, want the oldest event dropped", events)
+	}
+	if stats := broker.Stats()[0]; stats.Sent != 2 || stats.Lag != 0 {
+		t.Errorf("got sent %d and lag %d after draining, want 2 and 0", stats.Sent, stats.Lag)
+	}
+}
+
+func TestBrokerCoalescesLatestPerMachine(t *testing.T) {
+	broker := NewFleetBroker()
+	sub := subscribe(t, broker, SubscribeOptions{Policy: pb.SlowConsumerPolicy_COALESCE_LATEST, MaxLag: 10})
+
+	broker.Publish(&pb.FleetEvent{Type: pb.FleetEvent_ADDED, Machine: &pb.Machine{Id: 1, FuelLevel: 100}})
+	broker.Publish(updated(2, 100))
+	broker.Publish(updated(1, 90))
+	broker.Publish(updated(2, 90))
+	broker.Publish(updated(1, 80))
+
+	events := sub.Drain()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want one per machine", len(events))
+	}
+	if events[0].Type != pb.FleetEvent_ADDED || events[0].Machine.Id != 1 || events[0].Machine.FuelLevel != 80 {
+		t.Errorf("got %v, want machine 1 still added with its latest state", events[0])
+	}
+	if events[1].Machine.Id != 2 || events[1].Machine.FuelLevel != 90 {
+		t.Errorf("got %v, want the latest state of machine 2", events[1])
+	}
+	if coalesced := sub.coalesced.Load(); coalesced != 3 {
+		t.Errorf("got %d coalesced events, want 3", coalesced)
+	}
+
+	// Once drained, the next change is queued rather than merged into an event already sent
+	broker.Publish(updated(1, 70))
+	if events := sub.Drain(); len(events) != 1 || events[0].Type != pb.FleetEvent_UPDATED {
+		t.Errorf("got %v after draining, want a fresh update", events)
+	}
+}
+
+func TestBrokerDisconnectsLaggingSubscriber(t *testing.T) {
+	broker := NewFleetBroker()
+	sub := subscribe(t, broker, SubscribeOptions{Policy: pb.SlowConsumerPolicy_DISCONNECT, MaxLag: 2})
+
+	broker.Publish(updated(1, 0))
+	broker.Publish(updated(1, 0))
+	select {
+	case <-sub.Lagged():
+		t.Fatalf("subscriber disconnected at its max lag")
+	default:
+	}
+
+	broker.Publish(updated(1, 0))
+	select {
+	case <-sub.Lagged():
+	default:
+		t.Fatalf("subscriber was not disconnected past its max lag")
+	}
+	if status.Code(sub.laggedError()) != codes.ResourceExhausted {
+		t.Errorf("got %v, want ResourceExhausted", sub.laggedError())
+	}
+	if events := sub.Drain(); len(events) != 0 {
+		t.Errorf("disconnected subscriber still has %d events queued", len(events))
+	}
+}
+
+func TestParseSlowConsumerPolicy(t *testing.T) {
+	for name, want := range map[string]pb.SlowConsumerPolicy{
+		"drop-oldest":     pb.SlowConsumerPolicy_DROP_OLDEST,
+		"coalesce-latest": pb.SlowConsumerPolicy_COALESCE_LATEST,
+		"DISCONNECT":      pb.SlowConsumerPolicy_DISCONNECT,
+	} {
+		if got, err := parseSlowConsumerPolicy(name); err != nil || got != want {
+			t.Errorf("parseSlowConsumerPolicy(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "server-default", "block"} {
+		if _, err := parseSlowConsumerPolicy(name); err == nil {
+			t.Errorf("parseSlowConsumerPolicy(%q) succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxTimeScale    = 1000
+	maxStepTicks    = 100000
+	maxCatchUpTicks = 10 // ticks run back to back to catch up with wall time, beyond that they are skipped
+)
+
+// SimClock drives the simulation in fixed ticks of simulated time. Ticks run at
+// step/timeScale of wall time, can be paused, and can be stepped one at a time
+type SimClock struct {
+	mu        sync.Mutex
+	step      time.Duration // simulated time advanced per tick
+	timeScale float64       // simulated seconds per wall clock second
+	paused    bool
+	now       time.Time     // current simulated time
+	ticks     uint64        // ticks elapsed since start
+	changed   chan struct{} // wakes the run loop when the scale or pause state changes
+
+	tickMu sync.Mutex // serializes ticks from the run loop and StepSimulation
+	onTick func(clockTick)
+	stop   chan struct{}
+}
+
+// clockTick is passed to the tick function once per tick of simulated time
+type clockTick struct {
+	number  uint64
+	now     time.Time
+	elapsed time.Duration
+}
+
+// NewSimClock creates a running clock starting at start. onTick runs synchronously for every tick
+func NewSimClock(step time.Duration, start time.Time, onTick func(clockTick)) *SimClock {
+	return &SimClock{
+		step:      step,
+		timeScale: 1,
+		now:       start,
+		changed:   make(chan struct{}, 1),
+		onTick:    onTick,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Now returns the current simulated time
+func (c *SimClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// advance runs n ticks, each returning only once the tick function has applied it
+func (c *SimClock) advance(n int) {
+	c.tickMu.Lock()
+	defer c.tickMu.Unlock()
+
+	for range n {
+		c.mu.Lock()
+		c.now = c.now.Add(c.step)
+		c.ticks++
+		tick := clockTick{number: c.ticks, now: c.now, elapsed: c.step}
+		c.mu.Unlock()
+
+		c.onTick(tick)
+	}
+}
+
+// Run ticks the clock in wall time until Stop is called. Each tick is due one interval after the
+// last one was due, so neither the time a tick takes nor a change of scale makes the clock fall behind
+func (c *SimClock) Run() {
+	var due time.Time // when the last tick was due, zero while paused
+	for {
+		c.mu.Lock()
+		paused := c.paused
+		interval := time.Duration(float64(c.step) / c.timeScale)
+		c.mu.Unlock()
+
+		var timer <-chan time.Time
+		if paused {
+			due = time.Time{}
+		} else {
+			if now := time.Now(); due.IsZero() || now.Sub(due) > maxCatchUpTicks*interval {
+				// Started, resumed or too far behind to catch up
+				due = now
+			}
+			timer = time.After(time.Until(due.Add(interval)))
+		}
+
+		select {
+		case <-c.stop:
+			return
+		case <-c.changed:
+			// Recompute the interval with the new settings
+		case <-timer:
+			due = due.Add(interval)
+			c.advance(1)
+		}
+	}
+}
+
+func (c *SimClock) Stop() {
+	close(c.stop)
+}
+
+// update changes the clock settings and wakes the run loop
+func (c *SimClock) update(change func()) {
+	c.mu.Lock()
+	change()
+	c.mu.Unlock()
+
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (c *SimClock) toProto() *pb.SimulationState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &pb.SimulationState{
+		TimeScale: c.timeScale,
+		Paused:    c.paused,
+		SimTime:   timestamppb.New(c.now),
+		Ticks:     c.ticks,
+	}
+}
+
+// gRPC method to speed up or slow down simulated time relative to wall time
+func (mm *MachineManager) SetTimeScale(ctx context.Context, req *pb.SetTimeScaleRequest) (*pb.SimulationState, error) {
+	if req.TimeScale <= 0 || req.TimeScale > maxTimeScale {
+		return nil, status.Errorf(codes.InvalidArgument, "time scale must be in (0, %d]", maxTimeScale)
+	}
+
+	mm.clock.update(func() { mm.clock.timeScale = req.TimeScale })
+	return mm.clock.toProto(), nil
+}
+
+// gRPC method to freeze simulated time for every machine
+func (mm *MachineManager) PauseSimulation(ctx context.Context, req *pb.PauseSimulationRequest) (*pb.SimulationState, error) {
+	mm.clock.update(func() { mm.clock.paused = true })
+	return mm.clock.toProto(), nil
+}
+
+// gRPC method to let simulated time run again
+func (mm *MachineManager) ResumeSimulation(ctx context.Context, req *pb.ResumeSimulationRequest) (*pb.SimulationState, error) {
+	mm.clock.update(func() { mm.clock.paused = false })
+	return mm.clock.toProto(), nil
+}
+
+// gRPC method to advance a paused simulation by a number of ticks. Returns once every machine has applied them
+func (mm *MachineManager) StepSimulation(ctx context.Context, req *pb.StepSimulationRequest) (*pb.SimulationState, error) {
+	if req.Ticks == 0 || req.Ticks > maxStepTicks {
+		return nil, status.Errorf(codes.InvalidArgument, "ticks must be in [1, %d]", maxStepTicks)
+	}
+	if !mm.clock.toProto().Paused {
+		return nil, status.Error(codes.FailedPrecondition, "simulation must be paused to step")
+	}
+
+	mm.clock.advance(int(req.Ticks))
+	return mm.clock.toProto(), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStepSimulation(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if _, err := mm.StepSimulation(context.Background(), &pb.StepSimulationRequest{Ticks: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got %v, want FailedPrecondition while running", err)
+	}
+
+	paused, _ := mm.PauseSimulation(context.Background(), &pb.PauseSimulationRequest{})
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{MotionModel: pb.MotionModelType_DEAD_RECKONING})
+	mm.UnPause(context.Background(), &pb.Machine{Id: created.Id})
+
+	state, err := mm.StepSimulation(context.Background(), &pb.StepSimulationRequest{Ticks: 5})
+	if err != nil {
+		t.Fatalf("StepSimulation failed: %v", err)
+	}
+	if elapsed := state.SimTime.AsTime().Sub(paused.SimTime.AsTime()); elapsed != 5*mm.updateRate {
+		t.Errorf("simulated time advanced %v, want %v", elapsed, 5*mm.updateRate)
+	}
+
+	machine, _ := mm.GetMachine(context.Background(), &pb.Machine{Id: created.Id})
+	if machine.FuelLevel < 99.49 || machine.FuelLevel > 99.51 {
+		t.Errorf("got fuel %v, want 99.5 after five ticks", machine.FuelLevel)
+	}
+	if machine.Odometer < 9.99 || machine.Odometer > 10.01 {
+		t.Errorf("got odometer %v, want 10m after five ticks at 2m/s", machine.Odometer)
+	}
+	if !machine.Timestamp.AsTime().Equal(state.SimTime.AsTime()) {
+		t.Errorf("machine sampled at %v, want simulated time %v", machine.Timestamp.AsTime(), state.SimTime.AsTime())
+	}
+}
+
+func TestRunKeepsPaceWithSlowTicks(t *testing.T) {
+	var ticks atomic.Int64
+	clock := NewSimClock(10*time.Millisecond, time.Now(), func(clockTick) {
+		ticks.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	})
+	go clock.Run()
+	time.Sleep(500 * time.Millisecond)
+	clock.Stop()
+
+	// Waiting a full interval after each tick would manage about 33
+	if got := ticks.Load(); got < 45 {
+		t.Errorf("got %d ticks of 10ms in 500ms, want the time spent ticking not to add up", got)
+	}
+}
+
+func TestSetTimeScale(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if _, err := mm.SetTimeScale(context.Background(), &pb.SetTimeScaleRequest{TimeScale: 0}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument for zero time scale", err)
+	}
+
+	start := mm.clock.Now()
+	if _, err := mm.SetTimeScale(context.Background(), &pb.SetTimeScaleRequest{TimeScale: 100}); err != nil {
+		t.Fatalf("SetTimeScale failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// At 100x a one second tick runs every 10ms of wall time
+	if elapsed := mm.clock.Now().Sub(start); elapsed < 3*time.Second {
+		t.Errorf("simulated time advanced %v in 100ms at 100x", elapsed)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error reasons attached to gRPC status details, so clients can branch without parsing messages
+const (
+	errorDomain = "machinemap"
+
+	reasonMachineNotFound   = "MACHINE_NOT_FOUND"
+	reasonInvalidMachine    = "INVALID_MACHINE_ID"
+	reasonOutOfFuel         = "OUT_OF_FUEL"
+	reasonNotAtFuelStation  = "NOT_AT_FUEL_STATION"
+	reasonInvalidRefuelRate = "INVALID_REFUEL_RATE"
+	reasonInvalidMission    = "INVALID_MISSION"
+	reasonNoMission         = "NO_MISSION"
+)
+
+// machineError builds a gRPC status error whose details carry the machine id
+func machineError(code codes.Code, reason string, id uint32, msg string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"machine_id": strconv.FormatUint(uint64(id), 10)},
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+func errMachineNotFound(id uint32) error {
+	return machineError(codes.NotFound, reasonMachineNotFound, id, "machine "+strconv.FormatUint(uint64(id), 10)+" not found")
+}
+
+// validateMachineID rejects the zero id, which is never assigned to a machine
+func validateMachineID(id uint32) error {
+	if id == 0 {
+		return machineError(codes.InvalidArgument, reasonInvalidMachine, id, "machine id is required")
+	}
+	return nil
+}
+
+// lookupMachine validates the id and returns the machine or a NotFound status error
+func (mm *MachineManager) lookupMachine(id uint32) (*Machine, error) {
+	if err := validateMachineID(id); err != nil {
+		return nil, err
+	}
+	machine, exists := mm.getMachine(id)
+	if !exists {
+		return nil, errMachineNotFound(id)
+	}
+	return machine, nil
+}
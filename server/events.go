@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	pb "stream-machine-map-monitor/proto"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// publishEvent numbers an event about a machine and hands it to WatchEvents streams
+func (mm *MachineManager) publishEvent(machineID uint32, at time.Time, event *pb.Event) {
+	event.Id = mm.eventIDs.Add(1)
+	event.Timestamp = timestamppb.New(at)
+	event.MachineId = machineID
+	mm.events.Publish(event)
+}
+
+// gRPC method to stream events such as geofence breaches and alerts, for one machine or the whole fleet
+func (mm *MachineManager) WatchEvents(req *pb.WatchEventsRequest, stream pb.MachineMap_WatchEventsServer) error {
+	if req.MachineId != 0 {
+		if _, err := mm.lookupMachine(req.MachineId); err != nil {
+			return err
+		}
+	}
+
+	sub, err := mm.events.Subscribe(SubscribeOptions{
+		Method:  "WatchEvents",
+		Machine: req.MachineId,
+		Policy:  req.SlowConsumerPolicy,
+		MaxLag:  req.MaxLag,
+	})
+	if err != nil {
+		return err
+	}
+	defer mm.events.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Lagged():
+			log.Printf("Disconnecting slow WatchEvents subscriber %d", sub.id)
+			return sub.laggedError()
+		case <-sub.Ready():
+			for _, event := range sub.Drain() {
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
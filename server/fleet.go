@@ -0,0 +1,50 @@
+package main
+
+import (
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// stateChanged compares two samples of a machine, ignoring the sample timestamp so idle
+// machines do not produce updates. current must be owned by the caller, last is only read
+func stateChanged(last, current *pb.Machine) bool {
+	timestamp := current.Timestamp
+	current.Timestamp = last.Timestamp
+	defer func() { current.Timestamp = timestamp }()
+
+	return !proto.Equal(last, current)
+}
+
+// gRPC method to watch every machine on a single stream. Sends a snapshot of the fleet,
+// then the machines that are added, changed or removed as the changes are published
+func (mm *MachineManager) WatchFleet(req *pb.WatchFleetRequest, stream pb.MachineMap_WatchFleetServer) error {
+	// Subscribe before taking the snapshot so no change in between is missed
+	sub, err := mm.broker.Subscribe(SubscribeOptions{
+		Method: "WatchFleet",
+		Policy: req.SlowConsumerPolicy,
+		MaxLag: req.MaxLag,
+	})
+	if err != nil {
+		return err
+	}
+	defer mm.broker.Unsubscribe(sub)
+
+	mm.mu.RLock()
+	machines := make([]*Machine, 0, len(mm.machines))
+	for _, machine := range mm.machines {
+		machines = append(machines, machine)
+	}
+	mm.mu.RUnlock()
+
+	snapshot := &pb.FleetUpdate{}
+	for _, machine := range machines {
+		snapshot.Events = append(snapshot.Events, &pb.FleetEvent{Type: pb.FleetEvent_SNAPSHOT, Machine: mm.machineToProto(machine)})
+	}
+	if err := stream.Send(snapshot); err != nil {
+		return err
+	}
+
+	// Forward changes until the client disconnects, batching events that queued up while sending
+	return forwardFleet(stream, sub)
+}
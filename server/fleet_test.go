@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc"
+)
+
+// fakeFleetStream collects what WatchFleet sends
+type fakeFleetStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	updates chan *pb.FleetUpdate
+}
+
+func (f *fakeFleetStream) Context() context.Context { return f.ctx }
+
+func (f *fakeFleetStream) Send(update *pb.FleetUpdate) error {
+	f.updates <- update
+	return nil
+}
+
+func nextFleetUpdate(t *testing.T, stream *fakeFleetStream) *pb.FleetUpdate {
+	t.Helper()
+	select {
+	case update := <-stream.updates:
+		return update
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for fleet update")
+		return nil
+	}
+}
+
+func TestWatchFleet(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	mm.PauseSimulation(context.Background(), &pb.PauseSimulationRequest{})
+
+	first, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeFleetStream{ctx: ctx, updates: make(chan *pb.FleetUpdate, 16)}
+	go mm.WatchFleet(&pb.WatchFleetRequest{}, stream)
+
+	snapshot := nextFleetUpdate(t, stream)
+	if len(snapshot.Events) != 1 || snapshot.Events[0].Type != pb.FleetEvent_SNAPSHOT || snapshot.Events[0].Machine.Id != first.Id {
+		t.Fatalf("unexpected snapshot: %v", snapshot)
+	}
+
+	second, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	mm.UnPause(context.Background(), &pb.Machine{Id: first.Id})
+	mm.DeleteMachine(context.Background(), &pb.Machine{Id: second.Id})
+
+	want := []struct {
+		eventType pb.FleetEvent_Type
+		id        uint32
+	}{
+		{pb.FleetEvent_ADDED, second.Id},
+		{pb.FleetEvent_UPDATED, first.Id},
+		{pb.FleetEvent_REMOVED, second.Id},
+	}
+	var got []*pb.FleetEvent
+	for len(got) < len(want) {
+		got = append(got, nextFleetUpdate(t, stream).Events...)
+	}
+	for i, event := range got {
+		if event.Type != want[i].eventType || event.Machine.Id != want[i].id {
+			t.Errorf("event %d: got %v for machine %d, want %v for machine %d", i, event.Type, event.Machine.Id, want[i].eventType, want[i].id)
+		}
+	}
+
+	// Pausing stops the machine, after which ticks produce no more events
+	mm.Pause(context.Background(), &pb.Machine{Id: first.Id})
+	nextFleetUpdate(t, stream)
+	mm.StepSimulation(context.Background(), &pb.StepSimulationRequest{Ticks: 3})
+	select {
+	case update := <-stream.updates:
+		t.Errorf("idle machine produced an update: %v", update)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	pb "stream-machine-map-monitor/proto"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	maxFuelLevel       = 100
+	defaultFuelReserve = 10
+	returnSpeed        = 10.0 // meters per second, covers about a kilometre on the default reserve
+)
+
+// FuelStations is a fixed registry of places where machines may refuel
+type FuelStations struct {
+	locations    []*pb.GPS
+	radiusMeters float64 // how close a machine must be to a station to refuel
+}
+
+// WithFuelStations only lets machines refuel within radiusMeters of one of the stations
+func WithFuelStations(locations []*pb.GPS, radiusMeters float64) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.fuelStations = &FuelStations{locations: locations, radiusMeters: radiusMeters}
+	}
+}
+
+// inRange reports whether a machine at location can refuel. Always true without a registry
+func (fs *FuelStations) inRange(location *pb.GPS) bool {
+	if fs == nil {
+		return true
+	}
+	for _, station := range fs.locations {
+		if haversineMeters(station, location) <= fs.radiusMeters {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFuelStations parses "lat,lon;lat,lon" into station coordinates
+func parseFuelStations(spec string) ([]*pb.GPS, error) {
+	var stations []*pb.GPS
+	for _, pair := range strings.Split(spec, ";") {
+		parts := strings.Split(strings.TrimSpace(pair), ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("station %q is not lat,lon", pair)
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("station %q: %w", pair, err)
+		}
+		lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("station %q: %w", pair, err)
+		}
+		if !(math.Abs(lat) <= 90) || !(math.Abs(lon) <= 180) {
+			return nil, fmt.Errorf("station %q is not a valid coordinate", pair)
+		}
+		stations = append(stations, &pb.GPS{Lat: lat, Lon: lon})
+	}
+	return stations, nil
+}
+
+// WithFuelReserve sends moving machines straight home once their fuel drops below percent. 0 lets them run dry
+func WithFuelReserve(percent float32) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.fuelReserve = percent
+	}
+}
+
+// returnOnReserve abandons the machine's motion model or mission for a direct path home once its
+// fuel drops below the reserve. Caller must hold machine.mutex
+func (mm *MachineManager) returnOnReserve(machine *Machine) {
+	if machine.Status != pb.MachineStatus_MOVING || machine.FuelLevel >= mm.fuelReserve {
+		return
+	}
+	machine.mission = newMission(machine.Location, []*pb.GPS{machine.home}, returnSpeed, false)
+	machine.Status = pb.MachineStatus_RETURNING
+}
+
+// addFuel tops up a machine, capped at a full tank. Caller must hold machine.mutex
+func (m *Machine) addFuel(amount float32) {
+	m.FuelLevel = min(m.FuelLevel+amount, maxFuelLevel)
+	if m.FuelLevel > 0 && m.Status == pb.MachineStatus_OUT_OF_FUEL {
+		m.Status = pb.MachineStatus_IDLE
+	}
+	if m.FuelLevel == maxFuelLevel {
+		m.refuelRate = 0
+	}
+}
+
+// refuelStep adds fuel for one elapsed interval to a machine that is refueling.
+// Refueling stops if the machine leaves fuel station range. Caller must hold machine.mutex
+func (mm *MachineManager) refuelStep(machine *Machine, elapsed time.Duration) {
+	if machine.refuelRate == 0 {
+		return
+	}
+	if !mm.fuelStations.inRange(machine.Location) {
+		machine.refuelRate = 0
+		return
+	}
+	machine.addFuel(machine.refuelRate * float32(elapsed.Seconds()))
+}
+
+// gRPC method to refuel a machine, either instantly or gradually at req.Rate percent per second
+func (mm *MachineManager) Refuel(ctx context.Context, req *pb.RefuelRequest) (*pb.Machine, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	// Written so NaN fails too, as comparisons with it are false
+	if !(req.Rate >= 0) || math.IsInf(float64(req.Rate), 0) {
+		return nil, machineError(codes.InvalidArgument, reasonInvalidRefuelRate, req.Id, "refuel rate must be finite and not negative")
+	}
+
+	machine.mutex.Lock()
+	if !mm.fuelStations.inRange(machine.Location) {
+		machine.mutex.Unlock()
+		return nil, machineError(codes.FailedPrecondition, reasonNotAtFuelStation, req.Id, "machine is not within range of a fuel station")
+	}
+	if req.Rate == 0 {
+		machine.addFuel(maxFuelLevel)
+	} else {
+		machine.refuelRate = req.Rate
+	}
+	machine.mutex.Unlock()
+
+	return mm.changed(machine)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRefuelInstantly(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	machine, _ := mm.getMachine(created.Id)
+	machine.mutex.Lock()
+	machine.FuelLevel = 0
+	machine.Status = pb.MachineStatus_OUT_OF_FUEL
+	machine.mutex.Unlock()
+
+	refueled, err := mm.Refuel(context.Background(), &pb.RefuelRequest{Id: created.Id})
+	if err != nil {
+		t.Fatalf("Refuel failed: %v", err)
+	}
+	if refueled.FuelLevel != maxFuelLevel || refueled.Status != pb.MachineStatus_IDLE {
+		t.Errorf("got fuel %v status %v, want full tank and IDLE", refueled.FuelLevel, refueled.Status)
+	}
+
+	for _, rate := range []float32{-1, float32(math.NaN()), float32(math.Inf(1))} {
+		if _, err := mm.Refuel(context.Background(), &pb.RefuelRequest{Id: created.Id, Rate: rate}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("rate %v: got %v, want InvalidArgument", rate, err)
+		}
+	}
+}
+
+func TestRefuelAtRate(t *testing.T) {
+	machine := &Machine{Location: &pb.GPS{}, FuelLevel: 95, refuelRate: 2}
+	mm := NewMachineManager()
+	defer mm.Close()
+
+	mm.refuelStep(machine, time.Second)
+	if machine.FuelLevel != 97 {
+		t.Fatalf("got fuel %v, want 97", machine.FuelLevel)
+	}
+
+	mm.refuelStep(machine, 5*time.Second)
+	if machine.FuelLevel != maxFuelLevel || machine.refuelRate != 0 {
+		t.Errorf("got fuel %v rate %v, want full tank and refueling stopped", machine.FuelLevel, machine.refuelRate)
+	}
+}
+
+func TestRefuelRequiresFuelStation(t *testing.T) {
+	station := &pb.GPS{Lat: 47.7, Lon: -122.2}
+	mm := NewMachineManager(WithFuelStations([]*pb.GPS{station}, 25))
+
+	defer mm.Close()
+
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+
+	_, err := mm.Refuel(context.Background(), &pb.RefuelRequest{Id: created.Id})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got %v, want FailedPrecondition away from station", err)
+	}
+
+	machine, _ := mm.getMachine(created.Id)
+	machine.mutex.Lock()
+	machine.Location.Lat, machine.Location.Lon = station.Lat, station.Lon+0.0001
+	machine.mutex.Unlock()
+
+	if _, err := mm.Refuel(context.Background(), &pb.RefuelRequest{Id: created.Id}); err != nil {
+		t.Errorf("Refuel near station failed: %v", err)
+	}
+}
+
+func TestParseFuelStations(t *testing.T) {
+	stations, err := parseFuelStations("47.695, -122.145; 47.7,-122.2")
+	if err != nil {
+		t.Fatalf("parseFuelStations failed: %v", err)
+	}
+	if len(stations) != 2 || stations[1].Lat != 47.7 || stations[1].Lon != -122.2 {
+		t.Errorf("unexpected stations: %v", stations)
+	}
+	for _, spec := range []string{"47.695", "NaN,-122.2", "47.7,Inf", "91,0", "0,-181"} {
+		if _, err := parseFuelStations(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestReturnHomeOnReserve(t *testing.T) {
+	mm := NewMachineManager(WithFuelReserve(50))
+	defer mm.Close()
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+
+	created, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{MotionModel: pb.MotionModelType_DEAD_RECKONING, Speed: 5})
+	mm.UnPause(ctx, &pb.Machine{Id: created.Id})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 10})
+
+	machine, _ := mm.getMachine(created.Id)
+	machine.mutex.Lock()
+	machine.FuelLevel = 49
+	machine.mutex.Unlock()
+	away, _ := mm.GetMachine(ctx, &pb.Machine{Id: created.Id})
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	returning, _ := mm.GetMachine(ctx, &pb.Machine{Id: created.Id})
+	if returning.Status != pb.MachineStatus_RETURNING {
+		t.Fatalf("got status %v below the reserve, want RETURNING", returning.Status)
+	}
+	if got := haversineMeters(away.Location, created.Location) - haversineMeters(returning.Location, created.Location); got < returnSpeed-0.01 {
+		t.Errorf("got %.2f m closer to home, want %v", got, returnSpeed)
+	}
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 5})
+	parked, _ := mm.GetMachine(ctx, &pb.Machine{Id: created.Id})
+	if parked.Status != pb.MachineStatus_IDLE || parked.Mission != nil || haversineMeters(parked.Location, created.Location) > 0.01 {
+		t.Errorf("got %v at %v, want parked at home %v", parked.Status, parked.Location, created.Location)
+	}
+}
+
+func TestNoReserveRunsDry(t *testing.T) {
+	mm := NewMachineManager(WithFuelReserve(0))
+	defer mm.Close()
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+
+	created, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+	mm.UnPause(ctx, &pb.Machine{Id: created.Id})
+	machine, _ := mm.getMachine(created.Id)
+	machine.mutex.Lock()
+	machine.FuelLevel = 0.15
+	machine.mutex.Unlock()
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got, _ := mm.GetMachine(ctx, &pb.Machine{Id: created.Id}); got.Status != pb.MachineStatus_MOVING {
+		t.Fatalf("got status %v, want MOVING with no reserve", got.Status)
+	}
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got, _ := mm.GetMachine(ctx, &pb.Machine{Id: created.Id}); got.Status != pb.MachineStatus_OUT_OF_FUEL {
+		t.Errorf("got status %v, want OUT_OF_FUEL", got.Status)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	pb "stream-machine-map-monitor/proto"
+)
+
+const earthRadiusMeters = 6371000.0
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// haversineMeters returns the great-circle distance between two points, ignoring altitude
+func haversineMeters(from, to *pb.GPS) float64 {
+	lat1, lat2 := toRadians(from.Lat), toRadians(to.Lat)
+	dLat := lat2 - lat1
+	dLon := toRadians(to.Lon - from.Lon)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// bearingDegrees returns the initial bearing from one point to another,
+// in degrees clockwise from true north in [0, 360)
+func bearingDegrees(from, to *pb.GPS) float64 {
+	lat1, lat2 := toRadians(from.Lat), toRadians(to.Lat)
+	dLon := toRadians(to.Lon - from.Lon)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// destinationPoint travels distanceMeters from a point along a great circle with the
+// given initial bearing, and returns the latitude and longitude reached
+func destinationPoint(from *pb.GPS, bearing, distanceMeters float64) (float64, float64) {
+	lat1, lon1 := toRadians(from.Lat), toRadians(from.Lon)
+	theta := toRadians(bearing)
+	delta := distanceMeters / earthRadiusMeters
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return toDegrees(lat2), math.Mod(toDegrees(lon2)+540, 360) - 180
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"math"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const maxGeofenceVertices = 10_000
+
+// Geofence is a validated zone. Zones are immutable once created
+type Geofence struct {
+	proto                          *pb.Geofence
+	minLat, minLon, maxLat, maxLon float64 // bounding box, to skip most zones cheaply
+}
+
+// Geofences holds every zone. The list is replaced rather than modified, so the tick loop
+// reads it without locking
+type Geofences struct {
+	mu     sync.Mutex // serializes changes to the list
+	zones  atomic.Pointer[[]*Geofence]
+	lastID uint32
+}
+
+// newGeofence validates a zone and drops the closing vertex if the ring repeats its first one
+func newGeofence(zone *pb.Geofence) (*Geofence, error) {
+	if _, ok := pb.GeofenceType_name[int32(zone.Type)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown geofence type %d", zone.Type)
+	}
+	if _, ok := pb.GeofenceAction_name[int32(zone.Action)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown geofence action %d", zone.Action)
+	}
+
+	zone = proto.Clone(zone).(*pb.Geofence)
+	if n := len(zone.Ring); n > 1 && zone.Ring[0].Lat == zone.Ring[n-1].Lat && zone.Ring[0].Lon == zone.Ring[n-1].Lon {
+		zone.Ring = zone.Ring[:n-1]
+	}
+	if len(zone.Ring) < 3 || len(zone.Ring) > maxGeofenceVertices {
+		return nil, status.Errorf(codes.InvalidArgument, "geofence ring must have between 3 and %d vertices", maxGeofenceVertices)
+	}
+
+	g := &Geofence{proto: zone, minLat: 90, minLon: 180, maxLat: -90, maxLon: -180}
+	for _, vertex := range zone.Ring {
+		// Written so NaN fails too, as comparisons with it are false
+		if !(math.Abs(vertex.Lat) <= 90) || !(math.Abs(vertex.Lon) <= 180) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid geofence vertex %v, %v", vertex.Lat, vertex.Lon)
+		}
+		g.minLat, g.maxLat = min(g.minLat, vertex.Lat), max(g.maxLat, vertex.Lat)
+		g.minLon, g.maxLon = min(g.minLon, vertex.Lon), max(g.maxLon, vertex.Lon)
+	}
+	return g, nil
+}
+
+// contains reports whether a point lies inside the ring, by counting the edges a ray due east crosses.
+// Zones are small enough to treat latitude and longitude as planar
+func (g *Geofence) contains(location *pb.GPS) bool {
+	lat, lon := location.Lat, location.Lon
+	if lat < g.minLat || lat > g.maxLat || lon < g.minLon || lon > g.maxLon {
+		return false
+	}
+
+	inside := false
+	ring := g.proto.Ring
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > lat) != (b.Lat > lat) && lon < a.Lon+(lat-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// breachedBy reports whether a machine at location violates the zone
+func (g *Geofence) breachedBy(location *pb.GPS) bool {
+	if g.proto.Type == pb.GeofenceType_KEEP_IN {
+		return !g.contains(location)
+	}
+	return g.contains(location)
+}
+
+func (g *Geofences) list() []*Geofence {
+	if zones := g.zones.Load(); zones != nil {
+		return *zones
+	}
+	return nil
+}
+
+// add stores a validated zone under id, or under the next free id when id is 0
+func (g *Geofences) add(zone *Geofence, id uint32) *Geofence {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if id == 0 {
+		id = g.lastID + 1
+	}
+	g.lastID = max(g.lastID, id)
+	zone.proto.Id = id
+
+	zones := append(append([]*Geofence(nil), g.list()...), zone)
+	g.zones.Store(&zones)
+	return zone
+}
+
+func (g *Geofences) remove(id uint32) (*Geofence, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	zones := g.list()
+	for i, zone := range zones {
+		if zone.proto.Id == id {
+			remaining := append(append([]*Geofence(nil), zones[:i]...), zones[i+1:]...)
+			g.zones.Store(&remaining)
+			return zone, true
+		}
+	}
+	return nil, false
+}
+
+// enforceGeofences checks a machine against every zone after it advanced from previous, emitting an
+// event when it breaches a zone or gets back into compliance. Caller must hold machine.mutex
+func (mm *MachineManager) enforceGeofences(machine *Machine, previous *pb.GPS, now time.Time) {
+	zones := mm.geofences.list()
+	if len(zones) == 0 && len(machine.breaches) == 0 {
+		return
+	}
+
+	for _, zone := range zones {
+		id := zone.proto.Id
+		wasBreached := machine.breaches[id]
+		if !zone.breachedBy(machine.Location) {
+			if wasBreached {
+				delete(machine.breaches, id)
+				mm.publishBreach(machine.ID, zone, pb.GeofenceAction_NOTIFY, machine.Location, true, now)
+			}
+			continue
+		}
+		if wasBreached {
+			continue
+		}
+
+		action := zone.proto.Action
+		if action == pb.GeofenceAction_BOUNCE && (machine.mission != nil || zone.breachedBy(previous)) {
+			// A mission route would lead straight back in, and there is no compliant position to return to
+			action = pb.GeofenceAction_AUTO_PAUSE
+		}
+		breachedAt := &pb.GPS{Lat: machine.Location.Lat, Lon: machine.Location.Lon, Alt: machine.Location.Alt} // before bouncing
+
+		switch action {
+		case pb.GeofenceAction_BOUNCE:
+			machine.Location.Lat, machine.Location.Lon, machine.Location.Alt = previous.Lat, previous.Lon, previous.Alt
+			turnAround(machine.motion)
+		case pb.GeofenceAction_AUTO_PAUSE:
+			if !machine.isPaused() {
+				machine.Status = pb.MachineStatus_IDLE
+			}
+			machine.breaches[id] = true
+		default:
+			machine.breaches[id] = true
+		}
+		mm.publishBreach(machine.ID, zone, action, breachedAt, false, now)
+	}
+
+	// Forget zones that were deleted while the machine was in breach
+	for id := range machine.breaches {
+		if !slices.ContainsFunc(zones, func(zone *Geofence) bool { return zone.proto.Id == id }) {
+			delete(machine.breaches, id)
+		}
+	}
+}
+
+func (mm *MachineManager) publishBreach(machineID uint32, zone *Geofence, action pb.GeofenceAction, location *pb.GPS, cleared bool, now time.Time) {
+	mm.publishEvent(machineID, now, &pb.Event{Detail: &pb.Event_GeofenceBreach{GeofenceBreach: &pb.GeofenceBreach{
+		GeofenceId:   zone.proto.Id,
+		GeofenceName: zone.proto.Name,
+		GeofenceType: zone.proto.Type,
+		Action:       action,
+		Location:     &pb.GPS{Lat: location.Lat, Lon: location.Lon, Alt: location.Alt},
+		Cleared:      cleared,
+	}}})
+}
+
+// turnAround reverses the heading of motion models that keep one, so a bounced machine heads away from the zone
+func turnAround(motion MotionModel) {
+	switch m := motion.(type) {
+	case *CorrelatedRandomWalk:
+		m.heading = math.Mod(m.heading+180, 360)
+	case *DeadReckoning:
+		m.heading = math.Mod(m.heading+180, 360)
+	}
+}
+
+// gRPC method to add a keep-in or keep-out zone, checked against every machine on every tick
+func (mm *MachineManager) CreateGeofence(ctx context.Context, req *pb.Geofence) (*pb.Geofence, error) {
+	zone, err := newGeofence(req)
+	if err != nil {
+		return nil, err
+	}
+	return mm.geofences.add(zone, 0).proto, nil
+}
+
+// gRPC method to delete a zone, returning it
+func (mm *MachineManager) DeleteGeofence(ctx context.Context, req *pb.Geofence) (*pb.Geofence, error) {
+	zone, removed := mm.geofences.remove(req.Id)
+	if !removed {
+		return nil, status.Errorf(codes.NotFound, "geofence %d not found", req.Id)
+	}
+	return zone.proto, nil
+}
+
+// gRPC method to list every zone, ordered by id
+func (mm *MachineManager) ListGeofences(ctx context.Context, req *pb.ListGeofencesRequest) (*pb.ListGeofencesResponse, error) {
+	zones := mm.geofences.list()
+	resp := &pb.ListGeofencesResponse{Geofences: make([]*pb.Geofence, len(zones))}
+	for i, zone := range zones {
+		resp.Geofences[i] = zone.proto
+	}
+	return resp, nil
+}
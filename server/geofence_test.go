@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func box(minLat, minLon, maxLat, maxLon float64) []*pb.GPS {
+	return []*pb.GPS{{Lat: minLat, Lon: minLon}, {Lat: minLat, Lon: maxLon}, {Lat: maxLat, Lon: maxLon}, {Lat: maxLat, Lon: minLon}}
+}
+
+func TestGeofenceContains(t *testing.T) {
+	// An L shape, so the notch at the top right is outside
+	zone, err := newGeofence(&pb.Geofence{Ring: []*pb.GPS{
+		{Lat: 0, Lon: 0}, {Lat: 0, Lon: 2}, {Lat: 1, Lon: 2}, {Lat: 1, Lon: 1}, {Lat: 2, Lon: 1}, {Lat: 2, Lon: 0}, {Lat: 0, Lon: 0},
+	}})
+	if err != nil {
+		t.Fatalf("newGeofence failed: %v", err)
+	}
+	if len(zone.proto.Ring) != 6 {
+		t.Errorf("got %d vertices, want the closing vertex dropped", len(zone.proto.Ring))
+	}
+
+	tests := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{0.5, 0.5, true},
+		{0.5, 1.5, true},
+		{1.5, 0.5, true},
+		{1.5, 1.5, false}, // in the notch
+		{3, 0.5, false},
+		{0.5, -1, false},
+	}
+	for _, tt := range tests {
+		if got := zone.contains(&pb.GPS{Lat: tt.lat, Lon: tt.lon}); got != tt.want {
+			t.Errorf("contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestCreateGeofenceValidation(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for name, zone := range map[string]*pb.Geofence{
+		"too few vertices": {Ring: box(0, 0, 1, 1)[:2]},
+		"closed triangle":  {Ring: []*pb.GPS{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}, {Lat: 0, Lon: 0}}},
+		"invalid vertex":   {Ring: box(0, 0, 91, 1)},
+		"NaN vertex":       {Ring: box(0, 0, math.NaN(), 1)},
+		"infinite vertex":  {Ring: box(0, 0, 1, math.Inf(-1))},
+		"unknown type":     {Ring: box(0, 0, 1, 1), Type: 9},
+		"unknown action":   {Ring: box(0, 0, 1, 1), Action: 9},
+	} {
+		if _, err := mm.CreateGeofence(context.Background(), zone); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", name, err)
+		}
+	}
+
+	first, _ := mm.CreateGeofence(context.Background(), &pb.Geofence{Name: "a", Ring: box(0, 0, 1, 1)})
+	second, _ := mm.CreateGeofence(context.Background(), &pb.Geofence{Name: "b", Ring: box(0, 0, 1, 1)})
+	if first.Id != 1 || second.Id != 2 {
+		t.Errorf("got ids %d and %d, want 1 and 2", first.Id, second.Id)
+	}
+	if _, err := mm.DeleteGeofence(context.Background(), &pb.Geofence{Id: 1}); err != nil {
+		t.Errorf("DeleteGeofence failed: %v", err)
+	}
+	if _, err := mm.DeleteGeofence(context.Background(), &pb.Geofence{Id: 1}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleting twice: got %v, want NotFound", err)
+	}
+	if list, _ := mm.ListGeofences(context.Background(), &pb.ListGeofencesRequest{}); len(list.Geofences) != 1 || list.Geofences[0].Name != "b" {
+		t.Errorf("got geofences %v, want only b", list.Geofences)
+	}
+}
+
+// headingNorth creates a machine driving due north at 10 m/s, about 0.00009 degrees of latitude per tick
+func headingNorth(t *testing.T, mm *MachineManager) *pb.Machine {
+	t.Helper()
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+	machine, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{MotionModel: pb.MotionModelType_DEAD_RECKONING, Speed: 10})
+	mm.UnPause(ctx, &pb.Machine{Id: machine.Id})
+	return machine
+}
+
+func breaches(sub *Subscription[*pb.Event]) []*pb.GeofenceBreach {
+	var breaches []*pb.GeofenceBreach
+	for _, event := range sub.Drain() {
+		breaches = append(breaches, event.GetGeofenceBreach())
+	}
+	return breaches
+}
+
+func TestGeofenceBreachEvents(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	machine := headingNorth(t, mm)
+	sub, _ := mm.events.Subscribe(SubscribeOptions{})
+
+	// A strip the machine crosses in about two ticks
+	lat, lon := machine.Location.Lat, machine.Location.Lon
+	zone, _ := mm.CreateGeofence(ctx, &pb.Geofence{Name: "road", Ring: box(lat+0.00005, lon-0.001, lat+0.0002, lon+0.001)})
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	got := breaches(sub)
+	if len(got) != 1 || got[0].GeofenceId != zone.Id || got[0].Cleared || got[0].Action != pb.GeofenceAction_NOTIFY {
+		t.Fatalf("got %v, want one breach of the zone", got)
+	}
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := breaches(sub); len(got) != 0 {
+		t.Errorf("got %v while still inside, want a breach reported once", got)
+	}
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := breaches(sub); len(got) != 1 || !got[0].Cleared {
+		t.Errorf("got %v after leaving, want the breach cleared", got)
+	}
+}
+
+func TestGeofenceAutoPause(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	machine := headingNorth(t, mm)
+
+	lat, lon := machine.Location.Lat, machine.Location.Lon
+	mm.CreateGeofence(ctx, &pb.Geofence{Ring: box(lat+0.00005, lon-0.001, lat+0.001, lon+0.001), Action: pb.GeofenceAction_AUTO_PAUSE})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 3})
+
+	got, _ := mm.GetMachine(ctx, &pb.Machine{Id: machine.Id})
+	if got.Status != pb.MachineStatus_IDLE || got.Location.Lat > lat+0.0001 {
+		t.Errorf("got %v at %v, want paused at the zone edge", got.Status, got.Location)
+	}
+}
+
+func TestGeofenceBounceKeepsMachineInside(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	machine := headingNorth(t, mm)
+	sub, _ := mm.events.Subscribe(SubscribeOptions{})
+
+	lat, lon := machine.Location.Lat, machine.Location.Lon
+	mm.CreateGeofence(ctx, &pb.Geofence{
+		Type:   pb.GeofenceType_KEEP_IN,
+		Ring:   box(lat-0.001, lon-0.001, lat+0.00015, lon+0.001),
+		Action: pb.GeofenceAction_BOUNCE,
+	})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 2})
+
+	got := breaches(sub)
+	if len(got) != 1 || got[0].Action != pb.GeofenceAction_BOUNCE || got[0].Location.Lat < lat+0.00015 {
+		t.Fatalf("got %v, want one bounce reported where the machine left the zone", got)
+	}
+	bounced, _ := mm.GetMachine(ctx, &pb.Machine{Id: machine.Id})
+	if bounced.Status != pb.MachineStatus_MOVING || bounced.Location.Lat > lat+0.00015 {
+		t.Errorf("got %v at %v, want the machine moving and back inside", bounced.Status, bounced.Location)
+	}
+
+	// Turned around, it now heads south, away from the edge
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 2})
+	after, _ := mm.GetMachine(ctx, &pb.Machine{Id: machine.Id})
+	if after.Location.Lat >= bounced.Location.Lat || math.Abs(after.Heading-180) > 1 {
+		t.Errorf("got %v heading %v, want the machine heading south", after.Location, after.Heading)
+	}
+}
+
+// fakeEventStream collects what WatchEvents sends
+type fakeEventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.Event
+}
+
+func (f *fakeEventStream) Context() context.Context { return f.ctx }
+
+func (f *fakeEventStream) Send(event *pb.Event) error {
+	f.events <- event
+	return nil
+}
+
+func TestWatchEventsFiltersByMachine(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	machine := headingNorth(t, mm)
+	other, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+	if err := mm.WatchEvents(&pb.WatchEventsRequest{MachineId: 99}, &fakeEventStream{ctx: ctx}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown machine: got %v, want NotFound", err)
+	}
+
+	stream := &fakeEventStream{ctx: ctx, events: make(chan *pb.Event, 4)}
+	go mm.WatchEvents(&pb.WatchEventsRequest{MachineId: machine.Id}, stream)
+	for len(mm.events.Stats()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Both machines start inside the zone, only the watched one is reported
+	mm.CreateGeofence(ctx, &pb.Geofence{Ring: box(machine.Location.Lat-0.01, machine.Location.Lon-0.01, machine.Location.Lat+0.01, machine.Location.Lon+0.01)})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+
+	select {
+	case event := <-stream.events:
+		if event.MachineId != machine.Id || event.GetGeofenceBreach() == nil || event.Id == 0 {
+			t.Errorf("got %v, want a breach by machine %d", event, machine.Id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for event")
+	}
+	select {
+	case event := <-stream.events:
+		t.Errorf("got %v, want no event about machine %d", event, other.Id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -2,36 +2,116 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"math"
 	"math/rand/v2"
 	"net"
 	"os"
 	"os/signal"
 	pb "stream-machine-map-monitor/proto"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// MachineManager handles all machines through goroutines
+// MachineManager handles all machines, advancing them together on every simulation clock tick
 type MachineManager struct {
 	pb.UnimplementedMachineMapServer
 	machines map[uint32]*Machine // map of machines id to machine pointers
 	mu sync.RWMutex // thread-locking map of machines
 	nextID uint32
-	stopChans map[uint32]chan struct{} // signal goroutines to stop
 	updateRate time.Duration // rate at which time elapses for every machine
+	clock *SimClock // simulated time driving every machine
+	broker *Broker[*pb.FleetEvent] // pushes machine state changes to streams
+	events *Broker[*pb.Event] // pushes machine events to WatchEvents streams
+	eventIDs atomic.Uint64
+	fuelStations *FuelStations // nil when machines can refuel anywhere
+	fuelReserve float32 // fuel percent at which moving machines return home
+	geofences Geofences // zones machines must stay in or out of
+	alerts *Alerts // alerts raised by the alert rules
+	proximity *Proximity // nil when machines may overlap unreported
+	seeds *rand.Rand // source of machine seeds, guarded by mu. nil picks random seeds
+	trackLength int // points of position history kept per machine
+	store Store // persists the fleet across restarts, nil keeps it in memory only
+	persistMu sync.Mutex // serializes logged commands and snapshots
+	snapshotInterval time.Duration
+	stopSnapshots chan struct{}
+	snapshotsDone chan struct{}
 }
 
+// ManagerOption configures optional MachineManager behaviour
+type ManagerOption func(*MachineManager)
+
 // Creates the MachineManager at startup
-func NewMachineManager() *MachineManager {
-	return &MachineManager{
+func NewMachineManager(opts ...ManagerOption) *MachineManager {
+	mm := &MachineManager{
 		machines: make(map[uint32]*Machine),
 		nextID: 1,
-		stopChans: make(map[uint32]chan struct{}),
 		updateRate: 1000 * time.Millisecond,
+		trackLength: defaultTrackLength,
+		fuelReserve: defaultFuelReserve,
+		broker: NewFleetBroker(),
+		events: NewEventBroker(),
+		alerts: newAlerts(AlertRules{FuelBelow: defaultLowFuelPercent}),
+	}
+	for _, opt := range opts {
+		opt(mm)
+	}
+	mm.clock = NewSimClock(mm.updateRate, time.Now(), mm.tick)
+	if mm.store != nil {
+		mm.restore()
+	}
+	go mm.clock.Run()
+
+	if mm.store != nil && mm.snapshotInterval > 0 {
+		mm.stopSnapshots = make(chan struct{})
+		mm.snapshotsDone = make(chan struct{})
+		go mm.runSnapshots()
+	}
+	return mm
+}
+
+// Close stops the simulation clock, and with it every machine, then saves a final snapshot
+func (mm *MachineManager) Close() {
+	mm.clock.Stop()
+	if mm.store == nil {
+		return
+	}
+
+	if mm.stopSnapshots != nil {
+		close(mm.stopSnapshots)
+		<-mm.snapshotsDone
+	}
+	if err := mm.saveSnapshot(); err != nil {
+		log.Printf("Failed to save snapshot: %v", err)
+	}
+	if err := mm.store.Close(); err != nil {
+		log.Printf("Failed to close store: %v", err)
+	}
+}
+
+// WithSeed makes machine seeds, and so whole-fleet trajectories, reproducible across runs
+func WithSeed(seed uint64) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.seeds = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// nextSeed picks the seed for a new machine. Caller must hold mm.mu
+func (mm *MachineManager) nextSeed(req *pb.CreateMachineRequest) uint64 {
+	switch {
+	case req.Seed != nil:
+		return *req.Seed
+	case mm.seeds != nil:
+		return mm.seeds.Uint64()
+	default:
+		return rand.Uint64()
 	}
 }
 
@@ -39,191 +119,393 @@ func NewMachineManager() *MachineManager {
 type Machine struct {
 	ID uint32
 	Location *pb.GPS 
-	IsPaused bool
+	home *pb.GPS // spawn point, where the machine parks when it runs low on fuel
+	Status pb.MachineStatus
 	mutex sync.RWMutex
 	FuelLevel float32
-	brownian *BrownianMotion
+	motion MotionModel // how the machine wanders without a mission
+	seed uint64 // seeds the motion model's random source
+	source *rand.PCG // the motion model's random source, kept so its state can be persisted
+	fuelDrainRate float32 // fuel percent used per second of movement
+	Heading float64 // degrees from true north, from the last step
+	Speed float64 // meters per second, from the last step
+	Odometer float64 // cumulative meters travelled
+	SampledAt time.Time // when the state was last advanced
+	refuelRate float32 // fuel percent added per second while refueling, 0 when not refueling
+	published *pb.Machine // last state published to subscribers
+	removed bool // set once deleted, so no further events are published
+	mission *Mission // route followed instead of Brownian motion, nil when wandering
+	track *Track // recent position history
+	breaches map[uint32]bool // ids of the geofences the machine is in breach of
+	alerts map[pb.AlertRule]uint64 // ids of the machine's active alerts
+	pausedSince time.Time // when the machine was last seen becoming IDLE, zero unless IDLE
+	stillTicks int // consecutive ticks without moving while it should be moving
 }
 
-type BrownianMotion struct{
-	stepSizeLatLon float64
-	stepSizeAlt float64
-	fuelDrainRate float32
+// isPaused reports whether the machine is stood still. Caller must hold machine.mutex
+func (m *Machine) isPaused() bool {
+	return m.Status != pb.MachineStatus_MOVING && m.Status != pb.MachineStatus_RETURNING
+}
+
+// recordStep derives heading, speed and odometer from the move since previous. Caller must hold machine.mutex
+func (m *Machine) recordStep(previous *pb.GPS, elapsed time.Duration, now time.Time) {
+	distance := haversineMeters(previous, m.Location)
+	if distance > 0 {
+		m.Heading = bearingDegrees(previous, m.Location)
+	}
+	m.Speed = distance / elapsed.Seconds()
+	m.Odometer += distance
+	m.SampledAt = now
 }
 
-// createMachine creates a new machine with initial position
-func (mm *MachineManager) createMachine() *Machine {
+// createMachine creates a new machine with initial position and the requested motion model
+func (mm *MachineManager) createMachine(req *pb.CreateMachineRequest) (*Machine, error) {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
+	location := &pb.GPS{
+		Lat: 47.695185 + (0.0001 * (float64(mm.nextID%5) - 2)), // Start machine around Sammamish Valley, and nudge based on manipulation of ID
+		Lon: -122.145161 + (0.0001 * (float64(mm.nextID%5) - 2)),
+		Alt: float32(10 + mm.nextID%50), // Different starting altitudes from sea level
+	}
+	seed := mm.nextSeed(req)
+	source := rand.NewPCG(seed, seed)
+	motion, err := newMotionModel(req, location, rand.New(source))
+	if err != nil {
+		return nil, err
+	}
+
 	machine := &Machine{
 		ID: mm.nextID,
-		Location: &pb.GPS{
-			Lat: 47.695185 + (0.0001 * (float64(mm.nextID%5) - 2)), // Start machine around Sammamish Valley, and nudge based on manipulation of ID
-			Lon: -122.145161 + (0.0001 * (float64(mm.nextID%5) - 2)),
-			Alt: float32(10 + mm.nextID%50), // Different starting altitudes from sea level
-		},
-		IsPaused: true,
+		Location: location,
+		home: &pb.GPS{Lat: location.Lat, Lon: location.Lon, Alt: location.Alt},
+		Status: pb.MachineStatus_IDLE,
 		FuelLevel: 100.0, // Initially 100% FuelLevel
-		brownian: &BrownianMotion{
-			stepSizeLatLon: 0.0001,
-			stepSizeAlt: 1.0,
-			fuelDrainRate: 0.1,
-		},
+		motion: motion,
+		seed: seed,
+		source: source,
+		fuelDrainRate: 0.1,
+		SampledAt: mm.clock.Now(),
+		track: newTrack(mm.trackLength),
+		breaches: make(map[uint32]bool),
+		alerts: make(map[pb.AlertRule]uint64),
 	}
+	machine.track.record(machine.SampledAt, machine)
 
 	mm.machines[mm.nextID] = machine
 	mm.nextID++
 
-	return machine
+	return machine, nil
 }
 
 func (mm *MachineManager) machineToProto(machine *Machine) *pb.Machine {
 	machine.mutex.RLock()
 	defer machine.mutex.RUnlock()
 
+	return machine.toProto()
+}
+
+// toProto samples the machine state. Caller must hold machine.mutex
+func (m *Machine) toProto() *pb.Machine {
 	return &pb.Machine{
-		Id: machine.ID,
-		Location: machine.Location,
-		FuelLevel: machine.FuelLevel,
-		IsPaused: machine.IsPaused,
+		Id: m.ID,
+		Location: &pb.GPS{Lat: m.Location.Lat, Lon: m.Location.Lon, Alt: m.Location.Alt}, // copy so senders never race the scheduler
+		FuelLevel: m.FuelLevel,
+		IsPaused: m.isPaused(),
+		Timestamp: timestamppb.New(m.SampledAt),
+		Heading: m.Heading,
+		Speed: m.Speed,
+		Odometer: m.Odometer,
+		Status: m.Status,
+		Mission: m.missionProgress(),
+		MotionModel: m.motion.Type(),
+		Seed: m.seed,
 	}
 }
 
-func (mm *MachineManager) startMachineMovement(machine *Machine) {
-	// Create a new channel for the new machine so goroutine for movement can be stopped
-	stopChan := make(chan struct{})
-	mm.stopChans[machine.ID] = stopChan
-
-	// goroutine to update machine GPS location
-	go func() {
-		ticker := time.NewTicker(mm.updateRate)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-stopChan:
-				return
-
-			case <-ticker.C:
-				machine.mutex.Lock()
-				if !machine.IsPaused && machine.FuelLevel > 0 {
-					// Add Brownian motion to machine GPS location
-					machine.Location.Lat += (2.0 * (rand.Float64() - 0.5)) * machine.brownian.stepSizeLatLon
-					machine.Location.Lon += (2.0 * (rand.Float64() - 0.5)) * machine.brownian.stepSizeLatLon
-					machine.Location.Alt += float32((2.0 * (rand.Float64() - 0.5)) * machine.brownian.stepSizeAlt)
-
-					// Fuel drain with movement
-					machine.FuelLevel -= machine.brownian.fuelDrainRate
-					if machine.FuelLevel < 0 {
-						machine.FuelLevel = 0
-						machine.IsPaused = true
-					}
-				}
-				machine.mutex.Unlock()
-
+// advanceMachine moves a machine and drains its fuel for one elapsed step
+func (mm *MachineManager) advanceMachine(machine *Machine, elapsed time.Duration, now time.Time) {
+	machine.mutex.Lock()
+	defer machine.mutex.Unlock()
+
+	previous := &pb.GPS{Lat: machine.Location.Lat, Lon: machine.Location.Lon, Alt: machine.Location.Alt}
+	mm.refuelStep(machine, elapsed)
+	mm.returnOnReserve(machine)
+	if !machine.isPaused() && machine.FuelLevel > 0 {
+		if machine.mission != nil {
+			// Follow the mission route instead of wandering
+			if machine.mission.step(machine.Location, elapsed.Seconds()) {
+				machine.endMission()
 			}
+		} else {
+			machine.motion.Step(machine.Location, elapsed)
 		}
-	}()
+
+		// Fuel drain with movement
+		machine.FuelLevel -= machine.fuelDrainRate * float32(elapsed.Seconds())
+		if machine.FuelLevel <= 0 {
+			machine.FuelLevel = 0
+			machine.Status = pb.MachineStatus_OUT_OF_FUEL
+		}
+	}
+	mm.enforceGeofences(machine, previous, now)
+	mm.evaluateAlerts(machine, previous, now)
+	machine.recordStep(previous, elapsed, now)
+	machine.track.record(now, machine)
 }
 
 // gRPC method to pause machine
 func (mm *MachineManager) Pause(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
-	mm.mu.Lock()
-	defer mm.mu.Unlock()
-
-	machine, exists := mm.machines[req.Id]
-	if !exists {
-		return nil, nil
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
 	}
 
 	machine.mutex.Lock()
-	machine.IsPaused = true
+	if !machine.isPaused() {
+		machine.Status = pb.MachineStatus_IDLE
+	}
 	machine.mutex.Unlock()
 
-	return mm.machineToProto(machine), nil
+	return mm.changed(machine)
 }
 
 // gRPC method to unpause machine
 func (mm *MachineManager) UnPause(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
-	mm.mu.Lock()
-	defer mm.mu.Unlock()
-
-	machine, exists := mm.machines[req.Id]
-	if !exists {
-		return nil, nil
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
 	}
+
 	machine.mutex.Lock()
-	machine.IsPaused = false
+	if machine.FuelLevel <= 0 {
+		machine.mutex.Unlock()
+		return nil, machineError(codes.FailedPrecondition, reasonOutOfFuel, machine.ID, "machine has no fuel")
+	}
+	machine.Status = pb.MachineStatus_MOVING
 	machine.mutex.Unlock()
 
-	return mm.machineToProto(machine), nil
+	return mm.changed(machine)
+}
+
+// gRPC method to create a machine. The machine lives on the server until DeleteMachine is called
+func (mm *MachineManager) CreateMachine(ctx context.Context, req *pb.CreateMachineRequest) (*pb.Machine, error) {
+	machine, err := mm.createMachine(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return mm.notify(machine, pb.FleetEvent_ADDED), nil
 }
 
-// gRPC method implementation (same as from .proto). Instantiate machine and stream it as protobuf
+// gRPC method to delete a machine, returning its last known state
+func (mm *MachineManager) DeleteMachine(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	last, removed := mm.removeMachine(machine.ID)
+	if !removed {
+		return nil, errMachineNotFound(machine.ID)
+	}
+
+	return last, nil
+}
+
+// gRPC method implementation (same as from .proto). Stream an existing machine as protobuf
 func (mm *MachineManager) MachineStream(req *pb.MachineStreamRequest, stream pb.MachineMap_MachineStreamServer) error {
-	machine := mm.createMachine()
-
-	// Debugging: machineJSON after machine creation
-	// machineJSON, err := json.MarshalIndent(machine, "", "  ")
-	// if err != nil {
-	// 	log.Printf("Failed to marshal machine for logging: %v", err)
-	// } else {
-	// 	log.Printf("gRPC Server: after createMachine, before startMachineMovement:\n%s", machineJSON)
-	// }
-
-	// Start Brownian Motion of machine
-	mm.startMachineMovement(machine)
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before sending the initial state so no change in between is missed
+	sub, err := mm.broker.Subscribe(SubscribeOptions{
+		Method:  "MachineStream",
+		Machine: machine.ID,
+		Policy:  req.SlowConsumerPolicy,
+		MaxLag:  req.MaxLag,
+	})
+	if err != nil {
+		return err
+	}
+	defer mm.broker.Unsubscribe(sub)
+
 	// Send initial state immediately to avoid race conditions
-	if err := stream.Send(mm.machineToProto(machine)); err != nil {
-		mm.removeMachine(machine.ID)
+	initial := mm.machineToProto(machine)
+	if _, exists := mm.getMachine(machine.ID); !exists {
+		return nil
+	}
+	if err := stream.Send(initial); err != nil {
 		return err
 	}
 
-	// Stream updates until client (WebSocket) disconnects
-	for {
-		select {
-		case <-stream.Context().Done():
-			mm.removeMachine(machine.ID)
-			return nil
-		case <-time.After(mm.updateRate):
-			// Debugging: machineJSON after machine creation
-			// machineJSON, err := json.MarshalIndent(machine, "", "  ")
-			// if err != nil {
-			// 	log.Printf("Failed to marshal machine for logging: %v", err)
-			// } else {
-			// 	log.Printf("gRPC Server: after createMachine, before startMachineMovement:\n%s", machineJSON)
-			// }
-			if err := stream.Send(mm.machineToProto(machine)); err != nil {
-				mm.removeMachine(machine.ID)
-				return err
-			}
-		}
+	// Forward changes as they are published until client (WebSocket) disconnects or the machine is deleted
+	return forwardMachine(stream, sub)
+}
+
+// changed publishes a mutated machine's new state and returns it to the caller of a gRPC method
+func (mm *MachineManager) changed(machine *Machine) (*pb.Machine, error) {
+	sample := mm.notifyChange(machine)
+	if sample == nil {
+		return nil, errMachineNotFound(machine.ID)
 	}
+	return sample, nil
 }
 
-func (mm *MachineManager) removeMachine(id uint32) {
+// getMachine looks up a machine by id under the read lock
+func (mm *MachineManager) getMachine(id uint32) (*Machine, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	machine, exists := mm.machines[id]
+	return machine, exists
+}
+
+// removeMachine deletes a machine and publishes its removal. Returns the last state,
+// or false if the machine was already removed
+func (mm *MachineManager) removeMachine(id uint32) (*pb.Machine, bool) {
 	mm.mu.Lock()
-	defer mm.mu.Unlock()
+	machine, exists := mm.machines[id]
+	delete(mm.machines, id)
+	mm.mu.Unlock()
 
-	if stopChan, exists := mm.stopChans[id]; exists {
-		close(stopChan)
-		delete(mm.stopChans, id)
+	if !exists {
+		return nil, false
 	}
-	delete(mm.machines, id)
+	last := mm.notify(machine, pb.FleetEvent_REMOVED)
+	mm.clearAlerts(machine)
+	return last, true
 }
 
 func main() {
+	fuelStationsFlag := flag.String("fuel-stations", "", "semicolon separated lat,lon fuel station coordinates; machines refuel anywhere when empty")
+	fuelStationRadius := flag.Float64("fuel-station-radius", 25, "distance in meters from a fuel station within which a machine can refuel")
+	fuelReserve := flag.Float64("fuel-reserve", defaultFuelReserve, "fuel percent at which a moving machine heads straight home and parks; 0 lets machines run dry")
+	seed := flag.Uint64("seed", 0, "seed for reproducible machine trajectories; random when 0")
+	stateFile := flag.String("state-file", "", "file the fleet is saved to and restored from, as JSON when it ends in .json; in memory only when empty")
+	logCommands := flag.Bool("wal", false, "also log every command to a write-ahead log next to -state-file, so nothing since the last snapshot is lost")
+	trackLength := flag.Int("track-length", defaultTrackLength, "points of position history kept per machine for GetTrack; 0 disables history")
+	snapshotInterval := flag.Duration("snapshot-interval", defaultSnapshotInterval, "how often the fleet is saved to -state-file")
+	slowConsumerPolicy := flag.String("slow-consumer-policy", "drop-oldest", "default policy for streams that fall behind: drop-oldest, coalesce-latest or disconnect")
+	recordFile := flag.String("record", "", "record every machine update to this file for -replay")
+	replayFile := flag.String("replay", "", "serve a recording made with -record instead of simulating")
+	replaySpeed := flag.Float64("replay-speed", 1, "how many times faster than real time -replay plays the recording")
+	maxLag := flag.Int("max-lag", defaultMaxLag, "default number of updates queued for a stream before the slow consumer policy applies")
+	separation := flag.Float64("separation", 0, "report machines closer than this many meters to each other as proximity events; 0 disables")
+	avoidance := flag.String("avoidance", "no-avoidance", "what to do to one machine of a pair closer than -separation: no-avoidance, nudge-apart or pause-one")
+	alertFuelBelow := flag.Float64("alert-fuel-below", defaultLowFuelPercent, "raise an alert when a machine's fuel percent drops below this; 0 disables")
+	alertPausedFor := flag.Duration("alert-paused-for", 0, "raise an alert when a machine stays IDLE longer than this; 0 disables")
+	alertNoMovementTicks := flag.Int("alert-no-movement-ticks", 0, "raise an alert when a moving machine keeps its position for this many ticks; 0 disables")
+	alertMinAlt := flag.Float64("alert-min-alt", 0, "raise an alert when a machine flies below this altitude in meters; only with -alert-max-alt")
+	alertMaxAlt := flag.Float64("alert-max-alt", 0, "raise an alert when a machine flies above this altitude in meters; 0 disables the altitude band")
+	flag.Parse()
+
+	var opts []ManagerOption
+	if *seed != 0 {
+		opts = append(opts, WithSeed(*seed))
+	}
+	if *fuelStationsFlag != "" {
+		stations, err := parseFuelStations(*fuelStationsFlag)
+		if err != nil {
+			log.Fatalf("invalid -fuel-stations: %v", err)
+		}
+		if !(*fuelStationRadius > 0) || math.IsInf(*fuelStationRadius, 0) {
+			log.Fatalf("invalid -fuel-station-radius: must be positive and finite")
+		}
+		opts = append(opts, WithFuelStations(stations, *fuelStationRadius))
+	}
+	if *fuelReserve < 0 || *fuelReserve > maxFuelLevel {
+		log.Fatalf("invalid -fuel-reserve: must be in [0, %d]", maxFuelLevel)
+	}
+	opts = append(opts, WithFuelReserve(float32(*fuelReserve)))
+	policy, err := parseSlowConsumerPolicy(*slowConsumerPolicy)
+	if err != nil {
+		log.Fatalf("invalid -slow-consumer-policy: %v", err)
+	}
+	if *maxLag < 1 || *maxLag > maxMaxLag {
+		log.Fatalf("invalid -max-lag: must be in [1, %d]", maxMaxLag)
+	}
+	opts = append(opts, WithSlowConsumerPolicy(policy, *maxLag))
+	if *trackLength < 0 {
+		log.Fatalf("invalid -track-length: must not be negative")
+	}
+	opts = append(opts, WithTrackLength(*trackLength))
+	avoidancePolicy, err := parseAvoidancePolicy(*avoidance)
+	if err != nil {
+		log.Fatalf("invalid -avoidance: %v", err)
+	}
+	if *separation < 0 {
+		log.Fatalf("invalid -separation: must not be negative")
+	}
+	if *separation > 0 {
+		opts = append(opts, WithProximity(*separation, avoidancePolicy))
+	}
+	if *alertFuelBelow < 0 || *alertFuelBelow > 100 || *alertPausedFor < 0 || *alertNoMovementTicks < 0 {
+		log.Fatalf("invalid alert threshold: must not be negative, and fuel at most 100")
+	}
+	if *alertMaxAlt != 0 && *alertMaxAlt <= *alertMinAlt {
+		log.Fatalf("invalid -alert-max-alt: must be above -alert-min-alt")
+	}
+	opts = append(opts, WithAlertRules(AlertRules{
+		FuelBelow: float32(*alertFuelBelow),
+		PausedLongerThan: *alertPausedFor,
+		NoMovementTicks: *alertNoMovementTicks,
+		MinAltitude: float32(*alertMinAlt),
+		MaxAltitude: float32(*alertMaxAlt),
+	}))
+	if *stateFile != "" {
+		store, err := OpenFileStore(*stateFile, *logCommands)
+		if err != nil {
+			log.Fatalf("failed to open -state-file: %v", err)
+		}
+		opts = append(opts, WithStore(store, *snapshotInterval))
+	}
+
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		log.Fatalf("failted to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
-
-	machineManager := NewMachineManager()
+	var grpcServer *grpc.Server
+	var shutdown func()
+	if *replayFile != "" {
+		// Replay a recording, no machines are simulated
+		replay, err := OpenReplay(*replayFile, *replaySpeed)
+		if err != nil {
+			log.Fatalf("failed to open -replay: %v", err)
+		}
+		replay.broker.setDefaults(policy, *maxLag)
+		go func() {
+			if err := replay.Run(); err != nil {
+				log.Printf("Replay stopped: %v", err)
+			}
+		}()
+
+		grpcServer = grpc.NewServer()
+		pb.RegisterMachineMapServer(grpcServer, replay)
+		shutdown = replay.Close
+	} else {
+		machineManager := NewMachineManager(opts...)
+
+		var recorder *Recorder
+		if *recordFile != "" {
+			recorder, err = machineManager.StartRecording(*recordFile)
+			if err != nil {
+				log.Fatalf("failed to start -record: %v", err)
+			}
+		}
 
-	pb.RegisterMachineMapServer(grpcServer, machineManager) // Connect the MachineMapServer interface in machineManager to the gRPC server
+		grpcServer = grpc.NewServer(grpc.UnaryInterceptor(machineManager.LogCommands))
+		pb.RegisterMachineMapServer(grpcServer, machineManager) // Connect the MachineMapServer interface in machineManager to the gRPC server
+		shutdown = func() {
+			if recorder != nil {
+				recorder.Close()
+			}
+			machineManager.Close()
+		}
+	}
 
 	// Channel to receive OS signals
 	sigChan := make(chan os.Signal, 1)
@@ -242,6 +524,7 @@ func main() {
 	log.Println("Server gracefully shutting down...")
 
 	grpcServer.GracefulStop()
+	shutdown()
 	log.Println("Server stopped.")
 
 }
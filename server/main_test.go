@@ -1,15 +1,53 @@
 package main
 
 import (
+	"context"
+	"strconv"
 	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func setupTestServer(t *testing.T) (*MachineManager, func()) {
-	return nil, func() {}
+	mm := NewMachineManager()
+	return mm, mm.Close
 }
 
 func TestCreateMachine(t *testing.T) {
-	// placeholder
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	machine, err := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	if err != nil {
+		t.Fatalf("CreateMachine failed: %v", err)
+	}
+	if machine.Id != 1 || machine.Status != pb.MachineStatus_IDLE || machine.FuelLevel != 100 {
+		t.Errorf("unexpected initial machine state: %v", machine)
+	}
+	if _, exists := mm.getMachine(machine.Id); !exists {
+		t.Errorf("machine %d not registered with manager", machine.Id)
+	}
+}
+
+func TestDeleteMachine(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	machine, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	if _, err := mm.DeleteMachine(context.Background(), &pb.Machine{Id: machine.Id}); err != nil {
+		t.Fatalf("DeleteMachine failed: %v", err)
+	}
+	if _, exists := mm.getMachine(machine.Id); exists {
+		t.Errorf("machine %d still registered after delete", machine.Id)
+	}
+	if _, err := mm.DeleteMachine(context.Background(), &pb.Machine{Id: machine.Id}); err == nil {
+		t.Errorf("expected error deleting unknown machine")
+	}
 }
 
 func TestStartMachineMovement(t *testing.T) {
@@ -17,5 +55,72 @@ func TestStartMachineMovement(t *testing.T) {
 }
 
 func TestPauseAndUnpause(t *testing.T) {
-	// placeholder
-}
\ No newline at end of file
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+
+	machine, err := mm.UnPause(context.Background(), &pb.Machine{Id: created.Id})
+	if err != nil || machine.Status != pb.MachineStatus_MOVING {
+		t.Fatalf("UnPause: got %v, %v", machine, err)
+	}
+	machine, err = mm.Pause(context.Background(), &pb.Machine{Id: created.Id})
+	if err != nil || machine.Status != pb.MachineStatus_IDLE {
+		t.Fatalf("Pause: got %v, %v", machine, err)
+	}
+}
+
+func TestPauseAndUnpauseErrors(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	empty, _ := mm.getMachine(created.Id)
+	empty.mutex.Lock()
+	empty.FuelLevel = 0
+	empty.mutex.Unlock()
+
+	tests := []struct {
+		name string
+		call func(context.Context, *pb.Machine) (*pb.Machine, error)
+		id   uint32
+		code codes.Code
+	}{
+		{"pause unknown machine", mm.Pause, 42, codes.NotFound},
+		{"unpause unknown machine", mm.UnPause, 42, codes.NotFound},
+		{"pause missing id", mm.Pause, 0, codes.InvalidArgument},
+		{"unpause without fuel", mm.UnPause, created.Id, codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.call(context.Background(), &pb.Machine{Id: tt.id})
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Fatalf("got code %v, want %v", st.Code(), tt.code)
+			}
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			if !ok || info.Metadata["machine_id"] != strconv.FormatUint(uint64(tt.id), 10) {
+				t.Errorf("missing machine id in error details: %v", st.Details())
+			}
+		})
+	}
+}
+
+func TestRecordStep(t *testing.T) {
+	machine := &Machine{Location: &pb.GPS{Lat: 47.0001, Lon: -122}}
+	previous := &pb.GPS{Lat: 47, Lon: -122}
+
+	machine.recordStep(previous, 2*time.Second, time.Unix(10, 0))
+
+	// 0.0001 degrees of latitude is roughly 11.1 meters due north
+	if machine.Odometer < 11 || machine.Odometer > 11.2 {
+		t.Errorf("odometer: got %v, want ~11.1m", machine.Odometer)
+	}
+	if machine.Speed < 5.5 || machine.Speed > 5.6 {
+		t.Errorf("speed: got %v, want ~5.56m/s", machine.Speed)
+	}
+	if machine.Heading > 1e-6 {
+		t.Errorf("heading: got %v, want 0", machine.Heading)
+	}
+}
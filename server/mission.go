@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"math"
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Mission is an ordered route a machine follows instead of wandering
+type Mission struct {
+	waypoints        []*pb.GPS
+	speed            float64 // meters per second
+	wanderOnComplete bool
+	leg              int     // index of the waypoint the machine is heading to
+	totalDistance    float64 // meters from the start point through every waypoint
+	travelled        float64 // meters covered so far
+}
+
+// newMission plans a route from start through the waypoints
+func newMission(start *pb.GPS, waypoints []*pb.GPS, speed float64, wanderOnComplete bool) *Mission {
+	mission := &Mission{
+		waypoints:        waypoints,
+		speed:            speed,
+		wanderOnComplete: wanderOnComplete,
+	}
+
+	from := start
+	for _, waypoint := range waypoints {
+		mission.totalDistance += haversineMeters(from, waypoint)
+		from = waypoint
+	}
+	return mission
+}
+
+// step moves location along the route for the elapsed seconds and reports whether the mission is complete
+func (m *Mission) step(location *pb.GPS, seconds float64) bool {
+	budget := m.speed * seconds
+
+	for m.leg < len(m.waypoints) {
+		target := m.waypoints[m.leg]
+		remaining := haversineMeters(location, target)
+
+		if budget < remaining {
+			fraction := budget / remaining
+			location.Lat, location.Lon = destinationPoint(location, bearingDegrees(location, target), budget)
+			location.Alt += float32(float64(target.Alt-location.Alt) * fraction)
+			m.travelled += budget
+			return false
+		}
+
+		// Reach the waypoint and carry the leftover distance into the next leg
+		location.Lat, location.Lon, location.Alt = target.Lat, target.Lon, target.Alt
+		m.travelled += remaining
+		budget -= remaining
+		m.leg++
+	}
+	return true
+}
+
+func (m *Mission) toProto() *pb.MissionProgress {
+	percent := 100.0
+	if m.leg < len(m.waypoints) && m.totalDistance > 0 {
+		percent = min(100, 100*m.travelled/m.totalDistance)
+	}
+
+	return &pb.MissionProgress{
+		LegIndex:        uint32(min(m.leg, len(m.waypoints)-1)),
+		LegCount:        uint32(len(m.waypoints)),
+		PercentComplete: percent,
+	}
+}
+
+// endMission drops the machine's mission and falls back to wandering or idling. A machine returning
+// home parks. Caller must hold machine.mutex
+func (m *Machine) endMission() {
+	if m.Status == pb.MachineStatus_RETURNING || (!m.mission.wanderOnComplete && m.Status == pb.MachineStatus_MOVING) {
+		m.Status = pb.MachineStatus_IDLE
+	}
+	m.mission = nil
+}
+
+// validateMission rejects routes the machine cannot follow
+func validateMission(req *pb.AssignMissionRequest) error {
+	if len(req.Waypoints) == 0 {
+		return machineError(codes.InvalidArgument, reasonInvalidMission, req.Id, "mission needs at least one waypoint")
+	}
+	if req.Speed <= 0 || math.IsNaN(req.Speed) || math.IsInf(req.Speed, 0) {
+		return machineError(codes.InvalidArgument, reasonInvalidMission, req.Id, "mission speed must be positive and finite")
+	}
+	for _, waypoint := range req.Waypoints {
+		// Comparisons with NaN are false, so it is checked for explicitly
+		if waypoint == nil || math.IsNaN(waypoint.Lat) || math.IsNaN(waypoint.Lon) || math.IsNaN(float64(waypoint.Alt)) || math.IsInf(float64(waypoint.Alt), 0) ||
+			waypoint.Lat < -90 || waypoint.Lat > 90 || waypoint.Lon < -180 || waypoint.Lon > 180 {
+			return machineError(codes.InvalidArgument, reasonInvalidMission, req.Id, "mission waypoint is not a valid coordinate")
+		}
+	}
+	return nil
+}
+
+// gRPC method to send a machine along a route of waypoints. Replaces any current mission and starts the machine moving
+func (mm *MachineManager) AssignMission(ctx context.Context, req *pb.AssignMissionRequest) (*pb.Machine, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMission(req); err != nil {
+		return nil, err
+	}
+
+	machine.mutex.Lock()
+	if machine.FuelLevel <= 0 {
+		machine.mutex.Unlock()
+		return nil, machineError(codes.FailedPrecondition, reasonOutOfFuel, machine.ID, "machine has no fuel")
+	}
+	machine.mission = newMission(machine.Location, req.Waypoints, req.Speed, req.WanderOnComplete)
+	machine.Status = pb.MachineStatus_MOVING
+	machine.mutex.Unlock()
+
+	return mm.changed(machine)
+}
+
+// gRPC method to abandon a machine's mission
+func (mm *MachineManager) CancelMission(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	machine.mutex.Lock()
+	if machine.mission == nil {
+		machine.mutex.Unlock()
+		return nil, machineError(codes.FailedPrecondition, reasonNoMission, machine.ID, "machine has no mission")
+	}
+	machine.endMission()
+	machine.mutex.Unlock()
+
+	return mm.changed(machine)
+}
+
+// missionProgress reports progress of the current mission, or nil without one. Caller must hold machine.mutex
+func (m *Machine) missionProgress() *pb.MissionProgress {
+	if m.mission == nil {
+		return nil
+	}
+	return m.mission.toProto()
+}
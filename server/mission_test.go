@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMissionStep(t *testing.T) {
+	start := &pb.GPS{Lat: 47, Lon: -122}
+	waypoints := []*pb.GPS{
+		{Lat: 47.001, Lon: -122}, // ~111m north
+		{Lat: 47.001, Lon: -121.999},
+	}
+	mission := newMission(start, waypoints, 50, false)
+	location := &pb.GPS{Lat: start.Lat, Lon: start.Lon}
+
+	if mission.step(location, 1) {
+		t.Fatalf("mission completed after first step")
+	}
+	if progress := mission.toProto(); progress.LegIndex != 0 || progress.LegCount != 2 {
+		t.Errorf("unexpected progress after first step: %v", progress)
+	}
+	if travelled := haversineMeters(start, location); math.Abs(travelled-50) > 0.01 {
+		t.Errorf("travelled %vm, want 50m", travelled)
+	}
+
+	// The third step crosses the first waypoint and continues along the second leg
+	mission.step(location, 1)
+	mission.step(location, 1)
+	if progress := mission.toProto(); progress.LegIndex != 1 {
+		t.Errorf("got leg %d, want 1", progress.LegIndex)
+	}
+
+	for i := 0; i < 10 && !mission.step(location, 1); i++ {
+	}
+	if location.Lat != waypoints[1].Lat || location.Lon != waypoints[1].Lon {
+		t.Errorf("mission ended at %v, want final waypoint %v", location, waypoints[1])
+	}
+	if progress := mission.toProto(); progress.PercentComplete != 100 {
+		t.Errorf("got %v%% complete, want 100%%", progress.PercentComplete)
+	}
+}
+
+func TestAssignAndCancelMission(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	created, _ := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+
+	_, err := mm.AssignMission(context.Background(), &pb.AssignMissionRequest{Id: created.Id, Speed: 5})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument without waypoints", err)
+	}
+
+	for _, bad := range []*pb.AssignMissionRequest{
+		{Id: created.Id, Waypoints: []*pb.GPS{{Lat: 47.7, Lon: -122.15}}, Speed: math.NaN()},
+		{Id: created.Id, Waypoints: []*pb.GPS{{Lat: 47.7, Lon: -122.15}}, Speed: math.Inf(1)},
+		{Id: created.Id, Waypoints: []*pb.GPS{{Lat: math.NaN(), Lon: -122.15}}, Speed: 5},
+		{Id: created.Id, Waypoints: []*pb.GPS{{Lat: 47.7, Lon: math.NaN()}}, Speed: 5},
+		{Id: created.Id, Waypoints: []*pb.GPS{{Lat: 47.7, Lon: -122.15, Alt: float32(math.NaN())}}, Speed: 5},
+	} {
+		if _, err := mm.AssignMission(context.Background(), bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got %v, want InvalidArgument", bad, err)
+		}
+	}
+
+	machine, err := mm.AssignMission(context.Background(), &pb.AssignMissionRequest{
+		Id:        created.Id,
+		Waypoints: []*pb.GPS{{Lat: 47.7, Lon: -122.15}},
+		Speed:     5,
+	})
+	if err != nil {
+		t.Fatalf("AssignMission failed: %v", err)
+	}
+	if machine.Status != pb.MachineStatus_MOVING || machine.Mission == nil {
+		t.Errorf("got status %v mission %v, want MOVING with a mission", machine.Status, machine.Mission)
+	}
+
+	machine, err = mm.CancelMission(context.Background(), &pb.Machine{Id: created.Id})
+	if err != nil {
+		t.Fatalf("CancelMission failed: %v", err)
+	}
+	if machine.Status != pb.MachineStatus_IDLE || machine.Mission != nil {
+		t.Errorf("got status %v mission %v, want IDLE without a mission", machine.Status, machine.Mission)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	pb "stream-machine-map-monitor/proto"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultMotionSpeed = 2.0 // meters per second
+
+// MotionModel moves a machine that has no mission. Step is called with machine.mutex held
+type MotionModel interface {
+	Type() pb.MotionModelType
+	// Step advances location by the elapsed simulation time
+	Step(location *pb.GPS, elapsed time.Duration)
+}
+
+// newMotionModel builds the motion model requested for a machine spawning at home.
+// All randomness is drawn from rng so seeded machines move reproducibly
+func newMotionModel(req *pb.CreateMachineRequest, home *pb.GPS, rng *rand.Rand) (MotionModel, error) {
+	if req.Speed < 0 || math.IsNaN(req.Speed) || math.IsInf(req.Speed, 0) {
+		return nil, status.Error(codes.InvalidArgument, "speed must be finite and not negative")
+	}
+	if math.IsNaN(req.Heading) || math.IsInf(req.Heading, 0) {
+		return nil, status.Error(codes.InvalidArgument, "heading must be finite")
+	}
+	speed := req.Speed
+	if speed == 0 {
+		speed = defaultMotionSpeed
+	}
+
+	switch req.MotionModel {
+	case pb.MotionModelType_BROWNIAN:
+		return &BrownianMotion{rng: rng, stepSizeLatLon: 0.0001, stepSizeAlt: 1.0}, nil
+	case pb.MotionModelType_CORRELATED_RANDOM_WALK:
+		return &CorrelatedRandomWalk{rng: rng, speed: speed, heading: req.Heading, turnStdDev: 15}, nil
+	case pb.MotionModelType_ORNSTEIN_UHLENBECK:
+		return &OrnsteinUhlenbeck{
+			rng:           rng,
+			home:          &pb.GPS{Lat: home.Lat, Lon: home.Lon, Alt: home.Alt},
+			reversionRate: 0.05,
+			sigmaLatLon:   0.0001,
+			sigmaAlt:      1.0,
+		}, nil
+	case pb.MotionModelType_DEAD_RECKONING:
+		return &DeadReckoning{speed: speed, heading: req.Heading}, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown motion model %v", req.MotionModel)
+	}
+}
+
+// BrownianMotion jitters the machine independently every step
+type BrownianMotion struct {
+	rng            *rand.Rand
+	stepSizeLatLon float64 // maximum jitter in degrees per second of movement
+	stepSizeAlt    float64 // maximum jitter in meters per second of movement
+}
+
+func (b *BrownianMotion) Type() pb.MotionModelType { return pb.MotionModelType_BROWNIAN }
+
+func (b *BrownianMotion) Step(location *pb.GPS, elapsed time.Duration) {
+	// Random walk displacement grows with the square root of time
+	scale := math.Sqrt(elapsed.Seconds())
+	location.Lat += (2.0 * (b.rng.Float64() - 0.5)) * b.stepSizeLatLon * scale
+	location.Lon += (2.0 * (b.rng.Float64() - 0.5)) * b.stepSizeLatLon * scale
+	location.Alt += float32((2.0 * (b.rng.Float64() - 0.5)) * b.stepSizeAlt * scale)
+}
+
+// CorrelatedRandomWalk moves at a constant speed while the heading drifts, so the path wanders smoothly
+type CorrelatedRandomWalk struct {
+	rng        *rand.Rand
+	speed      float64 // meters per second
+	heading    float64 // degrees from true north
+	turnStdDev float64 // heading change in degrees per square root second
+}
+
+func (c *CorrelatedRandomWalk) Type() pb.MotionModelType {
+	return pb.MotionModelType_CORRELATED_RANDOM_WALK
+}
+
+func (c *CorrelatedRandomWalk) Step(location *pb.GPS, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	c.heading = math.Mod(c.heading+c.rng.NormFloat64()*c.turnStdDev*math.Sqrt(seconds)+360, 360)
+	location.Lat, location.Lon = destinationPoint(location, c.heading, c.speed*seconds)
+}
+
+// OrnsteinUhlenbeck wanders randomly but is pulled back towards a home point, so machines stay nearby
+type OrnsteinUhlenbeck struct {
+	rng           *rand.Rand
+	home          *pb.GPS
+	reversionRate float64 // share of the distance from home recovered per second
+	sigmaLatLon   float64 // noise in degrees per square root second
+	sigmaAlt      float64 // noise in meters per square root second
+}
+
+func (o *OrnsteinUhlenbeck) Type() pb.MotionModelType { return pb.MotionModelType_ORNSTEIN_UHLENBECK }
+
+func (o *OrnsteinUhlenbeck) Step(location *pb.GPS, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	noise := math.Sqrt(seconds)
+	location.Lat += o.reversionRate*(o.home.Lat-location.Lat)*seconds + o.sigmaLatLon*noise*o.rng.NormFloat64()
+	location.Lon += o.reversionRate*(o.home.Lon-location.Lon)*seconds + o.sigmaLatLon*noise*o.rng.NormFloat64()
+	location.Alt += float32(o.reversionRate*float64(o.home.Alt-location.Alt)*seconds + o.sigmaAlt*noise*o.rng.NormFloat64())
+}
+
+// DeadReckoning moves in a straight line at constant speed and heading
+type DeadReckoning struct {
+	speed   float64 // meters per second
+	heading float64 // degrees from true north
+}
+
+func (d *DeadReckoning) Type() pb.MotionModelType { return pb.MotionModelType_DEAD_RECKONING }
+
+func (d *DeadReckoning) Step(location *pb.GPS, elapsed time.Duration) {
+	location.Lat, location.Lon = destinationPoint(location, d.heading, d.speed*elapsed.Seconds())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewMotionModel(t *testing.T) {
+	home := &pb.GPS{Lat: 47, Lon: -122}
+	for name, modelType := range pb.MotionModelType_value {
+		model, err := newMotionModel(&pb.CreateMachineRequest{MotionModel: pb.MotionModelType(modelType)}, home, rand.New(rand.NewPCG(1, 1)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if model.Type() != pb.MotionModelType(modelType) {
+			t.Errorf("%s: got model type %v", name, model.Type())
+		}
+	}
+
+	if _, err := newMotionModel(&pb.CreateMachineRequest{MotionModel: 99}, home, rand.New(rand.NewPCG(1, 1))); err == nil {
+		t.Errorf("expected error for unknown motion model")
+	}
+	if _, err := newMotionModel(&pb.CreateMachineRequest{Speed: -1}, home, rand.New(rand.NewPCG(1, 1))); err == nil {
+		t.Errorf("expected error for negative speed")
+	}
+	for _, req := range []*pb.CreateMachineRequest{{Speed: math.NaN()}, {Speed: math.Inf(1)}, {Heading: math.NaN()}, {Heading: math.Inf(-1)}} {
+		if _, err := newMotionModel(req, home, rand.New(rand.NewPCG(1, 1))); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestConstantSpeedModels(t *testing.T) {
+	models := []MotionModel{
+		&DeadReckoning{speed: 3, heading: 90},
+		&CorrelatedRandomWalk{rng: rand.New(rand.NewPCG(1, 1)), speed: 3, heading: 90, turnStdDev: 15},
+	}
+
+	for _, model := range models {
+		start := &pb.GPS{Lat: 47, Lon: -122}
+		location := &pb.GPS{Lat: start.Lat, Lon: start.Lon}
+		model.Step(location, 2*time.Second)
+
+		if distance := haversineMeters(start, location); math.Abs(distance-6) > 0.01 {
+			t.Errorf("%v: moved %vm, want 6m", model.Type(), distance)
+		}
+	}
+}
+
+func TestOrnsteinUhlenbeckRevertsHome(t *testing.T) {
+	home := &pb.GPS{Lat: 47, Lon: -122, Alt: 10}
+	model := &OrnsteinUhlenbeck{rng: rand.New(rand.NewPCG(1, 1)), home: home, reversionRate: 0.5}
+	location := &pb.GPS{Lat: 47.01, Lon: -122.01, Alt: 30}
+
+	before := haversineMeters(home, location)
+	model.Step(location, time.Second)
+	after := haversineMeters(home, location)
+
+	if math.Abs(after-before/2) > 1 {
+		t.Errorf("distance from home went from %vm to %vm, want it halved", before, after)
+	}
+	if location.Alt != 20 {
+		t.Errorf("got altitude %v, want 20", location.Alt)
+	}
+}
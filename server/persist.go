@@ -0,0 +1,357 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultSnapshotInterval = 30 * time.Second
+
+// WithStore restores the fleet from store when the manager is created, then saves a snapshot
+// every interval and when the manager is closed
+func WithStore(store Store, interval time.Duration) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.store = store
+		mm.snapshotInterval = interval
+	}
+}
+
+// restore rebuilds the fleet and simulation clock from the store's snapshot, then replays
+// the commands logged after it. Runs before the clock starts, so nothing else touches the fleet
+func (mm *MachineManager) restore() {
+	state, records := mm.store.Load()
+
+	if state != nil {
+		if state.Simulation != nil {
+			mm.clock.restore(state.Simulation)
+		}
+		for _, record := range state.Machines {
+			machine, err := restoreMachine(record)
+			if err != nil {
+				log.Printf("Skipping machine %d from snapshot: %v", record.Machine.GetId(), err)
+				continue
+			}
+			// History is not persisted, the track starts again from the restored position
+			machine.track = newTrack(mm.trackLength)
+			machine.track.record(machine.SampledAt, machine)
+			mm.machines[machine.ID] = machine
+		}
+		mm.nextID = max(state.NextId, 1)
+		for _, zone := range state.Geofences {
+			if err := mm.restoreGeofence(zone); err != nil {
+				log.Printf("Skipping geofence %d from snapshot: %v", zone.Id, err)
+			}
+		}
+		mm.geofences.lastID = max(mm.geofences.lastID, state.NextGeofenceId-1)
+	}
+
+	// Movement since the snapshot is lost, commands are reapplied where the machines were then
+	for _, record := range records {
+		if err := mm.replay(record); err != nil {
+			log.Printf("Skipping write-ahead log record %d: %v", record.Sequence, err)
+		}
+	}
+	if state != nil || len(records) > 0 {
+		log.Printf("Restored %d machines from snapshot and %d logged commands", len(mm.machines), len(records))
+	}
+}
+
+// replay applies a logged command through the same method that first applied it
+func (mm *MachineManager) replay(record *pb.WALRecord) error {
+	ctx := context.Background()
+	var err error
+
+	switch command := record.Command.(type) {
+	case *pb.WALRecord_CreateMachine:
+		if record.MachineId < mm.nextID {
+			return fmt.Errorf("machine %d already exists", record.MachineId)
+		}
+		// Spawn points depend on the id, so reuse the one first assigned
+		mm.nextID = record.MachineId
+		_, err = mm.CreateMachine(ctx, command.CreateMachine)
+	case *pb.WALRecord_DeleteMachine:
+		_, err = mm.DeleteMachine(ctx, command.DeleteMachine)
+	case *pb.WALRecord_Pause:
+		_, err = mm.Pause(ctx, command.Pause)
+	case *pb.WALRecord_Unpause:
+		_, err = mm.UnPause(ctx, command.Unpause)
+	case *pb.WALRecord_Refuel:
+		_, err = mm.Refuel(ctx, command.Refuel)
+	case *pb.WALRecord_AssignMission:
+		_, err = mm.AssignMission(ctx, command.AssignMission)
+	case *pb.WALRecord_CancelMission:
+		_, err = mm.CancelMission(ctx, command.CancelMission)
+	case *pb.WALRecord_SetTimeScale:
+		_, err = mm.SetTimeScale(ctx, command.SetTimeScale)
+	case *pb.WALRecord_PauseSimulation:
+		_, err = mm.PauseSimulation(ctx, command.PauseSimulation)
+	case *pb.WALRecord_ResumeSimulation:
+		_, err = mm.ResumeSimulation(ctx, command.ResumeSimulation)
+	case *pb.WALRecord_CreateGeofence:
+		err = mm.restoreGeofence(command.CreateGeofence)
+	case *pb.WALRecord_DeleteGeofence:
+		_, err = mm.DeleteGeofence(ctx, command.DeleteGeofence)
+	default:
+		return fmt.Errorf("unknown command %T", command)
+	}
+	return err
+}
+
+// restoreGeofence adds a saved zone under the id it was first assigned
+func (mm *MachineManager) restoreGeofence(zone *pb.Geofence) error {
+	geofence, err := newGeofence(zone)
+	if err != nil {
+		return err
+	}
+	if zone.Id == 0 {
+		return fmt.Errorf("geofence has no id")
+	}
+	mm.geofences.add(geofence, zone.Id)
+	return nil
+}
+
+// commandRecorder returns how to log a successful call of method, or nil if the method changes nothing
+func commandRecorder(method string) func(req, resp any) *pb.WALRecord {
+	switch method {
+	case pb.MachineMap_CreateMachine_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			// Log the seed that was picked, so the machine moves the same way when replayed
+			created := resp.(*pb.Machine)
+			create := proto.Clone(req.(*pb.CreateMachineRequest)).(*pb.CreateMachineRequest)
+			create.Seed = &created.Seed
+			return &pb.WALRecord{Command: &pb.WALRecord_CreateMachine{CreateMachine: create}, MachineId: created.Id}
+		}
+	case pb.MachineMap_DeleteMachine_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_DeleteMachine{DeleteMachine: req.(*pb.Machine)}}
+		}
+	case pb.MachineMap_Pause_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_Pause{Pause: req.(*pb.Machine)}}
+		}
+	case pb.MachineMap_UnPause_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_Unpause{Unpause: req.(*pb.Machine)}}
+		}
+	case pb.MachineMap_Refuel_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_Refuel{Refuel: req.(*pb.RefuelRequest)}}
+		}
+	case pb.MachineMap_AssignMission_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_AssignMission{AssignMission: req.(*pb.AssignMissionRequest)}}
+		}
+	case pb.MachineMap_CancelMission_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_CancelMission{CancelMission: req.(*pb.Machine)}}
+		}
+	case pb.MachineMap_CreateGeofence_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_CreateGeofence{CreateGeofence: resp.(*pb.Geofence)}}
+		}
+	case pb.MachineMap_DeleteGeofence_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_DeleteGeofence{DeleteGeofence: req.(*pb.Geofence)}}
+		}
+	case pb.MachineMap_SetTimeScale_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_SetTimeScale{SetTimeScale: req.(*pb.SetTimeScaleRequest)}}
+		}
+	case pb.MachineMap_PauseSimulation_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_PauseSimulation{PauseSimulation: req.(*pb.PauseSimulationRequest)}}
+		}
+	case pb.MachineMap_ResumeSimulation_FullMethodName:
+		return func(req, resp any) *pb.WALRecord {
+			return &pb.WALRecord{Command: &pb.WALRecord_ResumeSimulation{ResumeSimulation: req.(*pb.ResumeSimulationRequest)}}
+		}
+	}
+	return nil
+}
+
+// LogCommands is a unary interceptor that appends every successful state-changing command to the
+// store's write-ahead log. Commands and snapshots are serialized so the log replays in the order
+// the commands were applied, and a snapshot never misses a command it claims to reflect
+func (mm *MachineManager) LogCommands(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	record := commandRecorder(info.FullMethod)
+	if mm.store == nil || record == nil {
+		return handler(ctx, req)
+	}
+
+	mm.persistMu.Lock()
+	defer mm.persistMu.Unlock()
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if err := mm.store.Append(record(req, resp)); err != nil {
+		log.Printf("Failed to log %s: %v", info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+// runSnapshots saves the fleet every snapshotInterval until the manager is closed
+func (mm *MachineManager) runSnapshots() {
+	defer close(mm.snapshotsDone)
+
+	ticker := time.NewTicker(mm.snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-mm.stopSnapshots:
+			return
+		case <-ticker.C:
+			if err := mm.saveSnapshot(); err != nil {
+				log.Printf("Failed to save snapshot: %v", err)
+			}
+		}
+	}
+}
+
+func (mm *MachineManager) saveSnapshot() error {
+	mm.persistMu.Lock()
+	defer mm.persistMu.Unlock()
+
+	return mm.store.Save(mm.fleetState())
+}
+
+// fleetState captures every machine and the simulation clock, ordered by machine id
+func (mm *MachineManager) fleetState() *pb.FleetState {
+	mm.mu.RLock()
+	state := &pb.FleetState{
+		NextId:     mm.nextID,
+		Simulation: mm.clock.toProto(),
+		Machines:   make([]*pb.MachineRecord, 0, len(mm.machines)),
+	}
+	for _, machine := range mm.machines {
+		machine.mutex.RLock()
+		state.Machines = append(state.Machines, machine.toRecord())
+		machine.mutex.RUnlock()
+	}
+	mm.mu.RUnlock()
+
+	mm.geofences.mu.Lock()
+	state.NextGeofenceId = mm.geofences.lastID + 1
+	mm.geofences.mu.Unlock()
+	for _, zone := range mm.geofences.list() {
+		state.Geofences = append(state.Geofences, zone.proto)
+	}
+
+	slices.SortFunc(state.Machines, func(a, b *pb.MachineRecord) int {
+		return cmp.Compare(a.Machine.Id, b.Machine.Id)
+	})
+	return state
+}
+
+// toRecord captures the machine for a snapshot. Caller must hold machine.mutex
+func (m *Machine) toRecord() *pb.MachineRecord {
+	record := &pb.MachineRecord{
+		Machine:    m.toProto(),
+		RefuelRate: m.refuelRate,
+		Home:       m.home,
+	}
+	if state, err := m.source.MarshalBinary(); err == nil {
+		record.RngState = state
+	}
+
+	switch motion := m.motion.(type) {
+	case *CorrelatedRandomWalk:
+		record.MotionSpeed, record.MotionHeading = motion.speed, motion.heading
+	case *DeadReckoning:
+		record.MotionSpeed, record.MotionHeading = motion.speed, motion.heading
+	case *OrnsteinUhlenbeck:
+		record.MotionHome = &pb.GPS{Lat: motion.home.Lat, Lon: motion.home.Lon, Alt: motion.home.Alt}
+	}
+
+	if m.mission != nil {
+		record.Mission = &pb.MissionRecord{
+			Waypoints:        m.mission.waypoints,
+			Speed:            m.mission.speed,
+			WanderOnComplete: m.mission.wanderOnComplete,
+			Leg:              uint32(m.mission.leg),
+			TotalDistance:    m.mission.totalDistance,
+			Travelled:        m.mission.travelled,
+		}
+	}
+	return record
+}
+
+// restoreMachine rebuilds a machine from its snapshot record, resuming its random source where it left off
+func restoreMachine(record *pb.MachineRecord) (*Machine, error) {
+	state := record.Machine
+	if state == nil || state.Id == 0 || state.Location == nil {
+		return nil, fmt.Errorf("incomplete machine record")
+	}
+
+	source := rand.NewPCG(state.Seed, state.Seed)
+	if len(record.RngState) > 0 {
+		if err := source.UnmarshalBinary(record.RngState); err != nil {
+			return nil, err
+		}
+	}
+	home := record.MotionHome
+	if home == nil {
+		home = state.Location
+	}
+	motion, err := newMotionModel(&pb.CreateMachineRequest{
+		MotionModel: state.MotionModel,
+		Speed:       record.MotionSpeed,
+		Heading:     record.MotionHeading,
+	}, home, rand.New(source))
+	if err != nil {
+		return nil, err
+	}
+
+	machine := &Machine{
+		ID:            state.Id,
+		Location:      state.Location,
+		home:          cmp.Or(record.Home, home),
+		Status:        state.Status,
+		FuelLevel:     state.FuelLevel,
+		motion:        motion,
+		seed:          state.Seed,
+		source:        source,
+		fuelDrainRate: 0.1,
+		Heading:       state.Heading,
+		Speed:         state.Speed,
+		Odometer:      state.Odometer,
+		SampledAt:     state.Timestamp.AsTime(),
+		refuelRate:    record.RefuelRate,
+		breaches:      make(map[uint32]bool),
+		alerts:        make(map[pb.AlertRule]uint64),
+	}
+	if mission := record.Mission; mission != nil {
+		machine.mission = &Mission{
+			waypoints:        mission.Waypoints,
+			speed:            mission.Speed,
+			wanderOnComplete: mission.WanderOnComplete,
+			leg:              int(mission.Leg),
+			totalDistance:    mission.TotalDistance,
+			travelled:        mission.Travelled,
+		}
+	}
+	return machine, nil
+}
+
+// restore resumes the clock from a saved simulation state. Must be called before Run
+func (c *SimClock) restore(state *pb.SimulationState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state.TimeScale > 0 {
+		c.timeScale = state.TimeScale
+	}
+	c.paused = state.Paused
+	c.now = state.SimTime.AsTime()
+	c.ticks = state.Ticks
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func openStore(t *testing.T, path string, logCommands bool) *FileStore {
+	t.Helper()
+	store, err := OpenFileStore(path, logCommands)
+	if err != nil {
+		t.Fatalf("OpenFileStore failed: %v", err)
+	}
+	return store
+}
+
+// callLogged runs a command through the LogCommands interceptor, as the gRPC server does
+func callLogged[Req, Resp any](t *testing.T, mm *MachineManager, method string, call func(context.Context, Req) (Resp, error), req Req) Resp {
+	t.Helper()
+	resp, err := mm.LogCommands(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req any) (any, error) { return call(ctx, req.(Req)) })
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	return resp.(Resp)
+}
+
+// crash stops a manager without saving a final snapshot
+func crash(mm *MachineManager) {
+	mm.clock.Stop()
+	mm.store.Close()
+}
+
+func fleetProtos(mm *MachineManager) map[uint32]*pb.Machine {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	machines := make(map[uint32]*pb.Machine, len(mm.machines))
+	for id, machine := range mm.machines {
+		machines[id] = mm.machineToProto(machine)
+	}
+	return machines
+}
+
+func assertSameFleet(t *testing.T, got, want map[uint32]*pb.Machine) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d machines, want %d", len(got), len(want))
+	}
+	for id, machine := range want {
+		if !proto.Equal(got[id], machine) {
+			t.Errorf("machine %d: got %v, want %v", id, got[id], machine)
+		}
+	}
+}
+
+// newPersistedFleet builds a paused simulation with one machine of every motion model, some moving
+func newPersistedFleet(t *testing.T, store Store) *MachineManager {
+	t.Helper()
+	mm := NewMachineManager(WithSeed(7), WithStore(store, 0))
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+
+	for _, model := range []pb.MotionModelType{
+		pb.MotionModelType_BROWNIAN,
+		pb.MotionModelType_CORRELATED_RANDOM_WALK,
+		pb.MotionModelType_ORNSTEIN_UHLENBECK,
+		pb.MotionModelType_DEAD_RECKONING,
+	} {
+		machine, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{MotionModel: model, Heading: 45})
+		mm.UnPause(ctx, &pb.Machine{Id: machine.Id})
+	}
+	_, err := mm.AssignMission(ctx, &pb.AssignMissionRequest{
+		Id:        1,
+		Waypoints: []*pb.GPS{{Lat: 47.7, Lon: -122.14}, {Lat: 47.71, Lon: -122.13}},
+		Speed:     5,
+	})
+	if err != nil {
+		t.Fatalf("AssignMission failed: %v", err)
+	}
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 5})
+	return mm
+}
+
+func TestSnapshotRestoresFleet(t *testing.T) {
+	for _, name := range []string{"fleet.pb", "fleet.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			mm := newPersistedFleet(t, openStore(t, path, false))
+			want := fleetProtos(mm)
+			original, _ := mm.getMachine(1)
+			mm.Close()
+
+			restored := NewMachineManager(WithStore(openStore(t, path, false), 0))
+			defer restored.Close()
+
+			assertSameFleet(t, fleetProtos(restored), want)
+			if state := restored.clock.toProto(); !state.Paused || state.Ticks != 5 {
+				t.Errorf("got simulation %v, want paused after 5 ticks", state)
+			}
+			if machine, _ := restored.getMachine(1); !proto.Equal(machine.home, original.home) {
+				t.Errorf("got home %v, want the spawn point %v", machine.home, original.home)
+			}
+			machine, _ := restored.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+			if machine.Id != 5 {
+				t.Errorf("got id %d for a new machine, want 5", machine.Id)
+			}
+		})
+	}
+}
+
+func TestRestoredMachinesMoveAsBefore(t *testing.T) {
+	dir := t.TempDir()
+	mm := newPersistedFleet(t, openStore(t, filepath.Join(dir, "fleet.pb"), false))
+	defer mm.Close()
+
+	if err := mm.saveSnapshot(); err != nil {
+		t.Fatalf("saveSnapshot failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "fleet.pb"))
+	os.WriteFile(filepath.Join(dir, "copy.pb"), data, 0o644)
+
+	// Random sources resume where they were, so both copies keep moving identically
+	restored := NewMachineManager(WithStore(openStore(t, filepath.Join(dir, "copy.pb"), false), 0))
+	defer restored.Close()
+	for _, fleet := range []*MachineManager{mm, restored} {
+		fleet.StepSimulation(context.Background(), &pb.StepSimulationRequest{Ticks: 10})
+	}
+	assertSameFleet(t, fleetProtos(restored), fleetProtos(mm))
+}
+
+func TestWriteAheadLogReplaysCommandsAfterSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.pb")
+	mm := newPersistedFleet(t, openStore(t, path, true))
+	if err := mm.saveSnapshot(); err != nil {
+		t.Fatalf("saveSnapshot failed: %v", err)
+	}
+
+	created := callLogged(t, mm, pb.MachineMap_CreateMachine_FullMethodName, mm.CreateMachine, &pb.CreateMachineRequest{})
+	callLogged(t, mm, pb.MachineMap_UnPause_FullMethodName, mm.UnPause, &pb.Machine{Id: created.Id})
+	callLogged(t, mm, pb.MachineMap_Pause_FullMethodName, mm.Pause, &pb.Machine{Id: 2})
+	callLogged(t, mm, pb.MachineMap_DeleteMachine_FullMethodName, mm.DeleteMachine, &pb.Machine{Id: 3})
+	want := fleetProtos(mm)
+	crash(mm)
+
+	restored := NewMachineManager(WithStore(openStore(t, path, true), 0))
+	defer restored.Close()
+
+	got := fleetProtos(restored)
+	if len(got) != len(want) {
+		t.Fatalf("got %d machines, want %d", len(got), len(want))
+	}
+	if _, exists := got[3]; exists {
+		t.Errorf("deleted machine 3 was restored")
+	}
+	if got[2].Status != pb.MachineStatus_IDLE {
+		t.Errorf("got machine 2 %v, want the logged pause replayed", got[2].Status)
+	}
+	if machine := got[created.Id]; machine == nil || machine.Seed != created.Seed || machine.Status != pb.MachineStatus_MOVING {
+		t.Errorf("got created machine %v, want it replayed with seed %d and moving", machine, created.Seed)
+	}
+	if !proto.Equal(got[created.Id].Location, created.Location) {
+		t.Errorf("got spawn point %v, want %v", got[created.Id].Location, created.Location)
+	}
+}
+
+func TestFileStoreTruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.pb")
+	store := openStore(t, path, true)
+	for id := uint32(1); id <= 2; id++ {
+		if err := store.Append(&pb.WALRecord{Command: &pb.WALRecord_Pause{Pause: &pb.Machine{Id: id}}}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	store.Close()
+
+	// A crash mid-write leaves a length prefix without its record
+	wal, _ := os.OpenFile(path+".wal", os.O_WRONLY|os.O_APPEND, 0)
+	wal.Write([]byte{0x20, 0x08})
+	wal.Close()
+
+	store = openStore(t, path, true)
+	defer store.Close()
+	if _, records := store.Load(); len(records) != 2 || records[1].Sequence != 2 {
+		t.Fatalf("got %v, want the two complete records", records)
+	}
+	if err := store.Append(&pb.WALRecord{Command: &pb.WALRecord_Pause{Pause: &pb.Machine{Id: 3}}}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	reopened := openStore(t, path, false)
+	if _, records := reopened.Load(); len(records) != 3 || records[2].Sequence != 3 {
+		t.Errorf("got %v after appending past the torn record, want three records", records)
+	}
+}
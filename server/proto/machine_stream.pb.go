@@ -9,6 +9,8 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,31 +23,2774 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type MachineStatus int32
+
+const (
+	MachineStatus_IDLE        MachineStatus = 0 // paused by an operator, or not yet started
+	MachineStatus_MOVING      MachineStatus = 1
+	MachineStatus_OUT_OF_FUEL MachineStatus = 2
+	MachineStatus_RETURNING   MachineStatus = 3 // heading back to its home point
+	MachineStatus_FAULT       MachineStatus = 4
+)
+
+// Enum value maps for MachineStatus.
+var (
+	MachineStatus_name = map[int32]string{
+		0: "IDLE",
+		1: "MOVING",
+		2: "OUT_OF_FUEL",
+		3: "RETURNING",
+		4: "FAULT",
+	}
+	MachineStatus_value = map[string]int32{
+		"IDLE":        0,
+		"MOVING":      1,
+		"OUT_OF_FUEL": 2,
+		"RETURNING":   3,
+		"FAULT":       4,
+	}
+)
+
+func (x MachineStatus) Enum() *MachineStatus {
+	p := new(MachineStatus)
+	*p = x
+	return p
+}
+
+func (x MachineStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MachineStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[0].Descriptor()
+}
+
+func (MachineStatus) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[0]
+}
+
+func (x MachineStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MachineStatus.Descriptor instead.
+func (MachineStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{0}
+}
+
+// How a machine moves when it has no mission
+type MotionModelType int32
+
+const (
+	MotionModelType_BROWNIAN               MotionModelType = 0 // independent random jitter each step
+	MotionModelType_CORRELATED_RANDOM_WALK MotionModelType = 1 // constant speed with a slowly drifting heading
+	MotionModelType_ORNSTEIN_UHLENBECK     MotionModelType = 2 // random walk pulled back towards the spawn point
+	MotionModelType_DEAD_RECKONING         MotionModelType = 3 // constant speed and heading
+)
+
+// Enum value maps for MotionModelType.
+var (
+	MotionModelType_name = map[int32]string{
+		0: "BROWNIAN",
+		1: "CORRELATED_RANDOM_WALK",
+		2: "ORNSTEIN_UHLENBECK",
+		3: "DEAD_RECKONING",
+	}
+	MotionModelType_value = map[string]int32{
+		"BROWNIAN":               0,
+		"CORRELATED_RANDOM_WALK": 1,
+		"ORNSTEIN_UHLENBECK":     2,
+		"DEAD_RECKONING":         3,
+	}
+)
+
+func (x MotionModelType) Enum() *MotionModelType {
+	p := new(MotionModelType)
+	*p = x
+	return p
+}
+
+func (x MotionModelType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MotionModelType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[1].Descriptor()
+}
+
+func (MotionModelType) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[1]
+}
+
+func (x MotionModelType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MotionModelType.Descriptor instead.
+func (MotionModelType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{1}
+}
+
+// What the server does when a stream client reads slower than updates are produced
+type SlowConsumerPolicy int32
+
+const (
+	SlowConsumerPolicy_SERVER_DEFAULT  SlowConsumerPolicy = 0 // the policy the server was started with
+	SlowConsumerPolicy_DROP_OLDEST     SlowConsumerPolicy = 1 // discard the oldest queued update once max_lag updates are queued
+	SlowConsumerPolicy_COALESCE_LATEST SlowConsumerPolicy = 2 // keep only the latest queued update for each machine
+	SlowConsumerPolicy_DISCONNECT      SlowConsumerPolicy = 3 // end the stream with RESOURCE_EXHAUSTED once more than max_lag updates are queued
+)
+
+// Enum value maps for SlowConsumerPolicy.
+var (
+	SlowConsumerPolicy_name = map[int32]string{
+		0: "SERVER_DEFAULT",
+		1: "DROP_OLDEST",
+		2: "COALESCE_LATEST",
+		3: "DISCONNECT",
+	}
+	SlowConsumerPolicy_value = map[string]int32{
+		"SERVER_DEFAULT":  0,
+		"DROP_OLDEST":     1,
+		"COALESCE_LATEST": 2,
+		"DISCONNECT":      3,
+	}
+)
+
+func (x SlowConsumerPolicy) Enum() *SlowConsumerPolicy {
+	p := new(SlowConsumerPolicy)
+	*p = x
+	return p
+}
+
+func (x SlowConsumerPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SlowConsumerPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[2].Descriptor()
+}
+
+func (SlowConsumerPolicy) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[2]
+}
+
+func (x SlowConsumerPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SlowConsumerPolicy.Descriptor instead.
+func (SlowConsumerPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{2}
+}
+
+type GeofenceType int32
+
+const (
+	GeofenceType_KEEP_OUT GeofenceType = 0 // machines must stay outside the polygon
+	GeofenceType_KEEP_IN  GeofenceType = 1 // machines must stay inside the polygon
+)
+
+// Enum value maps for GeofenceType.
+var (
+	GeofenceType_name = map[int32]string{
+		0: "KEEP_OUT",
+		1: "KEEP_IN",
+	}
+	GeofenceType_value = map[string]int32{
+		"KEEP_OUT": 0,
+		"KEEP_IN":  1,
+	}
+)
+
+func (x GeofenceType) Enum() *GeofenceType {
+	p := new(GeofenceType)
+	*p = x
+	return p
+}
+
+func (x GeofenceType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GeofenceType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[3].Descriptor()
+}
+
+func (GeofenceType) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[3]
+}
+
+func (x GeofenceType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GeofenceType.Descriptor instead.
+func (GeofenceType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{3}
+}
+
+// What the server does when a machine breaches a geofence, besides emitting an event
+type GeofenceAction int32
+
+const (
+	GeofenceAction_NOTIFY     GeofenceAction = 0
+	GeofenceAction_AUTO_PAUSE GeofenceAction = 1 // stop the machine where the breach was detected
+	GeofenceAction_BOUNCE     GeofenceAction = 2 // move the machine back to its last compliant position and turn it around
+)
+
+// Enum value maps for GeofenceAction.
+var (
+	GeofenceAction_name = map[int32]string{
+		0: "NOTIFY",
+		1: "AUTO_PAUSE",
+		2: "BOUNCE",
+	}
+	GeofenceAction_value = map[string]int32{
+		"NOTIFY":     0,
+		"AUTO_PAUSE": 1,
+		"BOUNCE":     2,
+	}
+)
+
+func (x GeofenceAction) Enum() *GeofenceAction {
+	p := new(GeofenceAction)
+	*p = x
+	return p
+}
+
+func (x GeofenceAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GeofenceAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[4].Descriptor()
+}
+
+func (GeofenceAction) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[4]
+}
+
+func (x GeofenceAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GeofenceAction.Descriptor instead.
+func (GeofenceAction) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{4}
+}
+
+// What the server does to one machine of a pair that gets too close
+type AvoidancePolicy int32
+
+const (
+	AvoidancePolicy_NO_AVOIDANCE AvoidancePolicy = 0 // only emit proximity events
+	AvoidancePolicy_NUDGE_APART  AvoidancePolicy = 1 // move the machine directly away from the other, clear of the separation distance
+	AvoidancePolicy_PAUSE_ONE    AvoidancePolicy = 2 // stop the machine where it is
+)
+
+// Enum value maps for AvoidancePolicy.
+var (
+	AvoidancePolicy_name = map[int32]string{
+		0: "NO_AVOIDANCE",
+		1: "NUDGE_APART",
+		2: "PAUSE_ONE",
+	}
+	AvoidancePolicy_value = map[string]int32{
+		"NO_AVOIDANCE": 0,
+		"NUDGE_APART":  1,
+		"PAUSE_ONE":    2,
+	}
+)
+
+func (x AvoidancePolicy) Enum() *AvoidancePolicy {
+	p := new(AvoidancePolicy)
+	*p = x
+	return p
+}
+
+func (x AvoidancePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AvoidancePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[5].Descriptor()
+}
+
+func (AvoidancePolicy) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[5]
+}
+
+func (x AvoidancePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AvoidancePolicy.Descriptor instead.
+func (AvoidancePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{5}
+}
+
+// The condition an alert watches for, each enabled by a server threshold
+type AlertRule int32
+
+const (
+	AlertRule_LOW_FUEL             AlertRule = 0 // fuel below a percentage
+	AlertRule_PAUSED_TOO_LONG      AlertRule = 1 // IDLE for longer than a duration
+	AlertRule_NO_MOVEMENT          AlertRule = 2 // MOVING or RETURNING without changing position for a number of ticks
+	AlertRule_ALTITUDE_OUT_OF_BAND AlertRule = 3 // altitude outside a band
+)
+
+// Enum value maps for AlertRule.
+var (
+	AlertRule_name = map[int32]string{
+		0: "LOW_FUEL",
+		1: "PAUSED_TOO_LONG",
+		2: "NO_MOVEMENT",
+		3: "ALTITUDE_OUT_OF_BAND",
+	}
+	AlertRule_value = map[string]int32{
+		"LOW_FUEL":             0,
+		"PAUSED_TOO_LONG":      1,
+		"NO_MOVEMENT":          2,
+		"ALTITUDE_OUT_OF_BAND": 3,
+	}
+)
+
+func (x AlertRule) Enum() *AlertRule {
+	p := new(AlertRule)
+	*p = x
+	return p
+}
+
+func (x AlertRule) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AlertRule) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[6].Descriptor()
+}
+
+func (AlertRule) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[6]
+}
+
+func (x AlertRule) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AlertRule.Descriptor instead.
+func (AlertRule) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{6}
+}
+
+type FleetEvent_Type int32
+
+const (
+	FleetEvent_SNAPSHOT FleetEvent_Type = 0 // machine state sent when the watch starts
+	FleetEvent_ADDED    FleetEvent_Type = 1
+	FleetEvent_UPDATED  FleetEvent_Type = 2
+	FleetEvent_REMOVED  FleetEvent_Type = 3 // machine holds the last known state
+)
+
+// Enum value maps for FleetEvent_Type.
+var (
+	FleetEvent_Type_name = map[int32]string{
+		0: "SNAPSHOT",
+		1: "ADDED",
+		2: "UPDATED",
+		3: "REMOVED",
+	}
+	FleetEvent_Type_value = map[string]int32{
+		"SNAPSHOT": 0,
+		"ADDED":    1,
+		"UPDATED":  2,
+		"REMOVED":  3,
+	}
+)
+
+func (x FleetEvent_Type) Enum() *FleetEvent_Type {
+	p := new(FleetEvent_Type)
+	*p = x
+	return p
+}
+
+func (x FleetEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FleetEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_machine_stream_proto_enumTypes[7].Descriptor()
+}
+
+func (FleetEvent_Type) Type() protoreflect.EnumType {
+	return &file_proto_machine_stream_proto_enumTypes[7]
+}
+
+func (x FleetEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FleetEvent_Type.Descriptor instead.
+func (FleetEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{16, 0}
+}
+
 type Machine struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Location  *GPS                   `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	FuelLevel float32                `protobuf:"fixed32,3,opt,name=fuel_level,json=fuelLevel,proto3" json:"fuel_level,omitempty"`
+	// Deprecated: Marked as deprecated in proto/machine_stream.proto.
+	IsPaused      bool                   `protobuf:"varint,4,opt,name=is_paused,json=isPaused,proto3" json:"is_paused,omitempty"` // use status; true unless MOVING or RETURNING
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                // server time the state was sampled
+	Heading       float64                `protobuf:"fixed64,6,opt,name=heading,proto3" json:"heading,omitempty"`                  // degrees clockwise from true north, from the last step
+	Speed         float64                `protobuf:"fixed64,7,opt,name=speed,proto3" json:"speed,omitempty"`                      // ground speed in meters per second, from the last step
+	Odometer      float64                `protobuf:"fixed64,8,opt,name=odometer,proto3" json:"odometer,omitempty"`                // cumulative distance travelled in meters
+	Status        MachineStatus          `protobuf:"varint,9,opt,name=status,proto3,enum=proto.MachineStatus" json:"status,omitempty"`
+	Mission       *MissionProgress       `protobuf:"bytes,10,opt,name=mission,proto3" json:"mission,omitempty"` // unset when the machine has no mission
+	MotionModel   MotionModelType        `protobuf:"varint,11,opt,name=motion_model,json=motionModel,proto3,enum=proto.MotionModelType" json:"motion_model,omitempty"`
+	Seed          uint64                 `protobuf:"varint,12,opt,name=seed,proto3" json:"seed,omitempty"` // seeds the machine's random motion; pass to CreateMachine to replay it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Machine) Reset() {
+	*x = Machine{}
+	mi := &file_proto_machine_stream_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Machine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Machine) ProtoMessage() {}
+
+func (x *Machine) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Machine.ProtoReflect.Descriptor instead.
+func (*Machine) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Machine) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Machine) GetLocation() *GPS {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Machine) GetFuelLevel() float32 {
+	if x != nil {
+		return x.FuelLevel
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in proto/machine_stream.proto.
+func (x *Machine) GetIsPaused() bool {
+	if x != nil {
+		return x.IsPaused
+	}
+	return false
+}
+
+func (x *Machine) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Machine) GetHeading() float64 {
+	if x != nil {
+		return x.Heading
+	}
+	return 0
+}
+
+func (x *Machine) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *Machine) GetOdometer() float64 {
+	if x != nil {
+		return x.Odometer
+	}
+	return 0
+}
+
+func (x *Machine) GetStatus() MachineStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MachineStatus_IDLE
+}
+
+func (x *Machine) GetMission() *MissionProgress {
+	if x != nil {
+		return x.Mission
+	}
+	return nil
+}
+
+func (x *Machine) GetMotionModel() MotionModelType {
+	if x != nil {
+		return x.MotionModel
+	}
+	return MotionModelType_BROWNIAN
+}
+
+func (x *Machine) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+type MissionProgress struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	LegIndex        uint32                 `protobuf:"varint,1,opt,name=leg_index,json=legIndex,proto3" json:"leg_index,omitempty"` // leg currently being travelled, leg 0 ends at the first waypoint
+	LegCount        uint32                 `protobuf:"varint,2,opt,name=leg_count,json=legCount,proto3" json:"leg_count,omitempty"`
+	PercentComplete float64                `protobuf:"fixed64,3,opt,name=percent_complete,json=percentComplete,proto3" json:"percent_complete,omitempty"` // share of the total mission distance travelled
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MissionProgress) Reset() {
+	*x = MissionProgress{}
+	mi := &file_proto_machine_stream_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MissionProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MissionProgress) ProtoMessage() {}
+
+func (x *MissionProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MissionProgress.ProtoReflect.Descriptor instead.
+func (*MissionProgress) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MissionProgress) GetLegIndex() uint32 {
+	if x != nil {
+		return x.LegIndex
+	}
+	return 0
+}
+
+func (x *MissionProgress) GetLegCount() uint32 {
+	if x != nil {
+		return x.LegCount
+	}
+	return 0
+}
+
+func (x *MissionProgress) GetPercentComplete() float64 {
+	if x != nil {
+		return x.PercentComplete
+	}
+	return 0
+}
+
+type GPS struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	Alt           float32                `protobuf:"fixed32,3,opt,name=alt,proto3" json:"alt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GPS) Reset() {
+	*x = GPS{}
+	mi := &file_proto_machine_stream_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GPS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GPS) ProtoMessage() {}
+
+func (x *GPS) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GPS.ProtoReflect.Descriptor instead.
+func (*GPS) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GPS) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *GPS) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *GPS) GetAlt() float32 {
+	if x != nil {
+		return x.Alt
+	}
+	return 0
+}
+
+// Streams an existing machine, created beforehand with CreateMachine
+type MachineStreamRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SlowConsumerPolicy SlowConsumerPolicy     `protobuf:"varint,2,opt,name=slow_consumer_policy,json=slowConsumerPolicy,proto3,enum=proto.SlowConsumerPolicy" json:"slow_consumer_policy,omitempty"`
+	MaxLag             uint32                 `protobuf:"varint,3,opt,name=max_lag,json=maxLag,proto3" json:"max_lag,omitempty"` // updates queued for the client before the policy applies, server default when 0
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MachineStreamRequest) Reset() {
+	*x = MachineStreamRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MachineStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MachineStreamRequest) ProtoMessage() {}
+
+func (x *MachineStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MachineStreamRequest.ProtoReflect.Descriptor instead.
+func (*MachineStreamRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MachineStreamRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MachineStreamRequest) GetSlowConsumerPolicy() SlowConsumerPolicy {
+	if x != nil {
+		return x.SlowConsumerPolicy
+	}
+	return SlowConsumerPolicy_SERVER_DEFAULT
+}
+
+func (x *MachineStreamRequest) GetMaxLag() uint32 {
+	if x != nil {
+		return x.MaxLag
+	}
+	return 0
+}
+
+type CreateMachineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MotionModel   MotionModelType        `protobuf:"varint,1,opt,name=motion_model,json=motionModel,proto3,enum=proto.MotionModelType" json:"motion_model,omitempty"`
+	Speed         float64                `protobuf:"fixed64,2,opt,name=speed,proto3" json:"speed,omitempty"`     // meters per second for the correlated walk and dead reckoning, defaults to 2
+	Heading       float64                `protobuf:"fixed64,3,opt,name=heading,proto3" json:"heading,omitempty"` // initial heading in degrees from true north
+	Seed          *uint64                `protobuf:"varint,4,opt,name=seed,proto3,oneof" json:"seed,omitempty"`  // defaults to the next seed from the server's -seed, or a random seed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMachineRequest) Reset() {
+	*x = CreateMachineRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMachineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMachineRequest) ProtoMessage() {}
+
+func (x *CreateMachineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMachineRequest.ProtoReflect.Descriptor instead.
+func (*CreateMachineRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateMachineRequest) GetMotionModel() MotionModelType {
+	if x != nil {
+		return x.MotionModel
+	}
+	return MotionModelType_BROWNIAN
+}
+
+func (x *CreateMachineRequest) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *CreateMachineRequest) GetHeading() float64 {
+	if x != nil {
+		return x.Heading
+	}
+	return 0
+}
+
+func (x *CreateMachineRequest) GetSeed() uint64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+type RefuelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Rate          float32                `protobuf:"fixed32,2,opt,name=rate,proto3" json:"rate,omitempty"` // fuel percent added per second; 0 refuels instantly
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefuelRequest) Reset() {
+	*x = RefuelRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefuelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefuelRequest) ProtoMessage() {}
+
+func (x *RefuelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefuelRequest.ProtoReflect.Descriptor instead.
+func (*RefuelRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RefuelRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RefuelRequest) GetRate() float32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+// Machine follows great-circle legs through the waypoints in order
+type AssignMissionRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Waypoints        []*GPS                 `protobuf:"bytes,2,rep,name=waypoints,proto3" json:"waypoints,omitempty"`
+	Speed            float64                `protobuf:"fixed64,3,opt,name=speed,proto3" json:"speed,omitempty"`                                                // meters per second
+	WanderOnComplete bool                   `protobuf:"varint,4,opt,name=wander_on_complete,json=wanderOnComplete,proto3" json:"wander_on_complete,omitempty"` // resume Brownian motion when the mission ends, otherwise go IDLE
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AssignMissionRequest) Reset() {
+	*x = AssignMissionRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignMissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignMissionRequest) ProtoMessage() {}
+
+func (x *AssignMissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignMissionRequest.ProtoReflect.Descriptor instead.
+func (*AssignMissionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AssignMissionRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AssignMissionRequest) GetWaypoints() []*GPS {
+	if x != nil {
+		return x.Waypoints
+	}
+	return nil
+}
+
+func (x *AssignMissionRequest) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *AssignMissionRequest) GetWanderOnComplete() bool {
+	if x != nil {
+		return x.WanderOnComplete
+	}
+	return false
+}
+
+type WatchFleetRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SlowConsumerPolicy SlowConsumerPolicy     `protobuf:"varint,1,opt,name=slow_consumer_policy,json=slowConsumerPolicy,proto3,enum=proto.SlowConsumerPolicy" json:"slow_consumer_policy,omitempty"`
+	MaxLag             uint32                 `protobuf:"varint,2,opt,name=max_lag,json=maxLag,proto3" json:"max_lag,omitempty"` // events queued for the client before the policy applies, server default when 0
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *WatchFleetRequest) Reset() {
+	*x = WatchFleetRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFleetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFleetRequest) ProtoMessage() {}
+
+func (x *WatchFleetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFleetRequest.ProtoReflect.Descriptor instead.
+func (*WatchFleetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchFleetRequest) GetSlowConsumerPolicy() SlowConsumerPolicy {
+	if x != nil {
+		return x.SlowConsumerPolicy
+	}
+	return SlowConsumerPolicy_SERVER_DEFAULT
+}
+
+func (x *WatchFleetRequest) GetMaxLag() uint32 {
+	if x != nil {
+		return x.MaxLag
+	}
+	return 0
+}
+
+type SimulationState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TimeScale     float64                `protobuf:"fixed64,1,opt,name=time_scale,json=timeScale,proto3" json:"time_scale,omitempty"` // simulated seconds per wall clock second
+	Paused        bool                   `protobuf:"varint,2,opt,name=paused,proto3" json:"paused,omitempty"`
+	SimTime       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=sim_time,json=simTime,proto3" json:"sim_time,omitempty"`
+	Ticks         uint64                 `protobuf:"varint,4,opt,name=ticks,proto3" json:"ticks,omitempty"` // ticks elapsed since the server started
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulationState) Reset() {
+	*x = SimulationState{}
+	mi := &file_proto_machine_stream_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulationState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulationState) ProtoMessage() {}
+
+func (x *SimulationState) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulationState.ProtoReflect.Descriptor instead.
+func (*SimulationState) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SimulationState) GetTimeScale() float64 {
+	if x != nil {
+		return x.TimeScale
+	}
+	return 0
+}
+
+func (x *SimulationState) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *SimulationState) GetSimTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SimTime
+	}
+	return nil
+}
+
+func (x *SimulationState) GetTicks() uint64 {
+	if x != nil {
+		return x.Ticks
+	}
+	return 0
+}
+
+type SetTimeScaleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TimeScale     float64                `protobuf:"fixed64,1,opt,name=time_scale,json=timeScale,proto3" json:"time_scale,omitempty"` // e.g. 10 runs the simulation ten times faster, at most 1000
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetTimeScaleRequest) Reset() {
+	*x = SetTimeScaleRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetTimeScaleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTimeScaleRequest) ProtoMessage() {}
+
+func (x *SetTimeScaleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTimeScaleRequest.ProtoReflect.Descriptor instead.
+func (*SetTimeScaleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SetTimeScaleRequest) GetTimeScale() float64 {
+	if x != nil {
+		return x.TimeScale
+	}
+	return 0
+}
+
+type PauseSimulationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseSimulationRequest) Reset() {
+	*x = PauseSimulationRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSimulationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSimulationRequest) ProtoMessage() {}
+
+func (x *PauseSimulationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseSimulationRequest.ProtoReflect.Descriptor instead.
+func (*PauseSimulationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{10}
+}
+
+type ResumeSimulationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeSimulationRequest) Reset() {
+	*x = ResumeSimulationRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeSimulationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeSimulationRequest) ProtoMessage() {}
+
+func (x *ResumeSimulationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeSimulationRequest.ProtoReflect.Descriptor instead.
+func (*ResumeSimulationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{11}
+}
+
+// Only allowed while the simulation is paused
+type StepSimulationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticks         uint32                 `protobuf:"varint,1,opt,name=ticks,proto3" json:"ticks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StepSimulationRequest) Reset() {
+	*x = StepSimulationRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepSimulationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepSimulationRequest) ProtoMessage() {}
+
+func (x *StepSimulationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepSimulationRequest.ProtoReflect.Descriptor instead.
+func (*StepSimulationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StepSimulationRequest) GetTicks() uint32 {
+	if x != nil {
+		return x.Ticks
+	}
+	return 0
+}
+
+// Area bounded by south-west and north-east corners, in degrees
+type BoundingBox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinLat        float64                `protobuf:"fixed64,1,opt,name=min_lat,json=minLat,proto3" json:"min_lat,omitempty"`
+	MinLon        float64                `protobuf:"fixed64,2,opt,name=min_lon,json=minLon,proto3" json:"min_lon,omitempty"`
+	MaxLat        float64                `protobuf:"fixed64,3,opt,name=max_lat,json=maxLat,proto3" json:"max_lat,omitempty"`
+	MaxLon        float64                `protobuf:"fixed64,4,opt,name=max_lon,json=maxLon,proto3" json:"max_lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BoundingBox) Reset() {
+	*x = BoundingBox{}
+	mi := &file_proto_machine_stream_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoundingBox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoundingBox) ProtoMessage() {}
+
+func (x *BoundingBox) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoundingBox.ProtoReflect.Descriptor instead.
+func (*BoundingBox) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BoundingBox) GetMinLat() float64 {
+	if x != nil {
+		return x.MinLat
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMinLon() float64 {
+	if x != nil {
+		return x.MinLon
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMaxLat() float64 {
+	if x != nil {
+		return x.MaxLat
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMaxLon() float64 {
+	if x != nil {
+		return x.MaxLon
+	}
+	return 0
+}
+
+// Unset filters match every machine
+type ListMachinesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      uint32                 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // defaults to 50, capped at 1000
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token from a previous response
+	IsPaused      *bool                  `protobuf:"varint,3,opt,name=is_paused,json=isPaused,proto3,oneof" json:"is_paused,omitempty"`
+	MinFuelLevel  *float32               `protobuf:"fixed32,4,opt,name=min_fuel_level,json=minFuelLevel,proto3,oneof" json:"min_fuel_level,omitempty"`
+	MaxFuelLevel  *float32               `protobuf:"fixed32,5,opt,name=max_fuel_level,json=maxFuelLevel,proto3,oneof" json:"max_fuel_level,omitempty"`
+	Bounds        *BoundingBox           `protobuf:"bytes,6,opt,name=bounds,proto3" json:"bounds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMachinesRequest) Reset() {
+	*x = ListMachinesRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMachinesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMachinesRequest) ProtoMessage() {}
+
+func (x *ListMachinesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMachinesRequest.ProtoReflect.Descriptor instead.
+func (*ListMachinesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListMachinesRequest) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListMachinesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListMachinesRequest) GetIsPaused() bool {
+	if x != nil && x.IsPaused != nil {
+		return *x.IsPaused
+	}
+	return false
+}
+
+func (x *ListMachinesRequest) GetMinFuelLevel() float32 {
+	if x != nil && x.MinFuelLevel != nil {
+		return *x.MinFuelLevel
+	}
+	return 0
+}
+
+func (x *ListMachinesRequest) GetMaxFuelLevel() float32 {
+	if x != nil && x.MaxFuelLevel != nil {
+		return *x.MaxFuelLevel
+	}
+	return 0
+}
+
+func (x *ListMachinesRequest) GetBounds() *BoundingBox {
+	if x != nil {
+		return x.Bounds
+	}
+	return nil
+}
+
+type ListMachinesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Machines      []*Machine             `protobuf:"bytes,1,rep,name=machines,proto3" json:"machines,omitempty"`                                  // ordered by id
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMachinesResponse) Reset() {
+	*x = ListMachinesResponse{}
+	mi := &file_proto_machine_stream_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMachinesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMachinesResponse) ProtoMessage() {}
+
+func (x *ListMachinesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMachinesResponse.ProtoReflect.Descriptor instead.
+func (*ListMachinesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListMachinesResponse) GetMachines() []*Machine {
+	if x != nil {
+		return x.Machines
+	}
+	return nil
+}
+
+func (x *ListMachinesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// A change to a single machine in the fleet
+type FleetEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          FleetEvent_Type        `protobuf:"varint,1,opt,name=type,proto3,enum=proto.FleetEvent_Type" json:"type,omitempty"`
+	Machine       *Machine               `protobuf:"bytes,2,opt,name=machine,proto3" json:"machine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FleetEvent) Reset() {
+	*x = FleetEvent{}
+	mi := &file_proto_machine_stream_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FleetEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FleetEvent) ProtoMessage() {}
+
+func (x *FleetEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FleetEvent.ProtoReflect.Descriptor instead.
+func (*FleetEvent) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *FleetEvent) GetType() FleetEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return FleetEvent_SNAPSHOT
+}
+
+func (x *FleetEvent) GetMachine() *Machine {
+	if x != nil {
+		return x.Machine
+	}
+	return nil
+}
+
+// Batch of fleet events produced in one update interval
+type FleetUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*FleetEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FleetUpdate) Reset() {
+	*x = FleetUpdate{}
+	mi := &file_proto_machine_stream_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FleetUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FleetUpdate) ProtoMessage() {}
+
+func (x *FleetUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FleetUpdate.ProtoReflect.Descriptor instead.
+func (*FleetUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FleetUpdate) GetEvents() []*FleetEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// Fleet state saved by the persistence layer and restored when the server starts
+type FleetState struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NextId         uint32                 `protobuf:"varint,1,opt,name=next_id,json=nextId,proto3" json:"next_id,omitempty"`
+	WalSequence    uint64                 `protobuf:"varint,2,opt,name=wal_sequence,json=walSequence,proto3" json:"wal_sequence,omitempty"` // last write-ahead log record reflected in this state
+	Simulation     *SimulationState       `protobuf:"bytes,3,opt,name=simulation,proto3" json:"simulation,omitempty"`
+	Machines       []*MachineRecord       `protobuf:"bytes,4,rep,name=machines,proto3" json:"machines,omitempty"`
+	Geofences      []*Geofence            `protobuf:"bytes,5,rep,name=geofences,proto3" json:"geofences,omitempty"`
+	NextGeofenceId uint32                 `protobuf:"varint,6,opt,name=next_geofence_id,json=nextGeofenceId,proto3" json:"next_geofence_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FleetState) Reset() {
+	*x = FleetState{}
+	mi := &file_proto_machine_stream_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FleetState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FleetState) ProtoMessage() {}
+
+func (x *FleetState) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FleetState.ProtoReflect.Descriptor instead.
+func (*FleetState) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *FleetState) GetNextId() uint32 {
+	if x != nil {
+		return x.NextId
+	}
+	return 0
+}
+
+func (x *FleetState) GetWalSequence() uint64 {
+	if x != nil {
+		return x.WalSequence
+	}
+	return 0
+}
+
+func (x *FleetState) GetSimulation() *SimulationState {
+	if x != nil {
+		return x.Simulation
+	}
+	return nil
+}
+
+func (x *FleetState) GetMachines() []*MachineRecord {
+	if x != nil {
+		return x.Machines
+	}
+	return nil
+}
+
+func (x *FleetState) GetGeofences() []*Geofence {
+	if x != nil {
+		return x.Geofences
+	}
+	return nil
+}
+
+func (x *FleetState) GetNextGeofenceId() uint32 {
+	if x != nil {
+		return x.NextGeofenceId
+	}
+	return 0
+}
+
+// Machine state, including the internals Machine does not expose
+type MachineRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Machine       *Machine               `protobuf:"bytes,1,opt,name=machine,proto3" json:"machine,omitempty"`
+	RefuelRate    float32                `protobuf:"fixed32,2,opt,name=refuel_rate,json=refuelRate,proto3" json:"refuel_rate,omitempty"`
+	RngState      []byte                 `protobuf:"bytes,3,opt,name=rng_state,json=rngState,proto3" json:"rng_state,omitempty"` // motion model random source
+	MotionSpeed   float64                `protobuf:"fixed64,4,opt,name=motion_speed,json=motionSpeed,proto3" json:"motion_speed,omitempty"`
+	MotionHeading float64                `protobuf:"fixed64,5,opt,name=motion_heading,json=motionHeading,proto3" json:"motion_heading,omitempty"`
+	MotionHome    *GPS                   `protobuf:"bytes,6,opt,name=motion_home,json=motionHome,proto3" json:"motion_home,omitempty"`
+	Mission       *MissionRecord         `protobuf:"bytes,7,opt,name=mission,proto3" json:"mission,omitempty"` // unset when the machine has no mission
+	Home          *GPS                   `protobuf:"bytes,8,opt,name=home,proto3" json:"home,omitempty"`       // spawn point the machine returns to on low fuel
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MachineRecord) Reset() {
+	*x = MachineRecord{}
+	mi := &file_proto_machine_stream_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MachineRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MachineRecord) ProtoMessage() {}
+
+func (x *MachineRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MachineRecord.ProtoReflect.Descriptor instead.
+func (*MachineRecord) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *MachineRecord) GetMachine() *Machine {
+	if x != nil {
+		return x.Machine
+	}
+	return nil
+}
+
+func (x *MachineRecord) GetRefuelRate() float32 {
+	if x != nil {
+		return x.RefuelRate
+	}
+	return 0
+}
+
+func (x *MachineRecord) GetRngState() []byte {
+	if x != nil {
+		return x.RngState
+	}
+	return nil
+}
+
+func (x *MachineRecord) GetMotionSpeed() float64 {
+	if x != nil {
+		return x.MotionSpeed
+	}
+	return 0
+}
+
+func (x *MachineRecord) GetMotionHeading() float64 {
+	if x != nil {
+		return x.MotionHeading
+	}
+	return 0
+}
+
+func (x *MachineRecord) GetMotionHome() *GPS {
+	if x != nil {
+		return x.MotionHome
+	}
+	return nil
+}
+
+func (x *MachineRecord) GetMission() *MissionRecord {
+	if x != nil {
+		return x.Mission
+	}
+	return nil
+}
+
+func (x *MachineRecord) GetHome() *GPS {
+	if x != nil {
+		return x.Home
+	}
+	return nil
+}
+
+type MissionRecord struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Waypoints        []*GPS                 `protobuf:"bytes,1,rep,name=waypoints,proto3" json:"waypoints,omitempty"`
+	Speed            float64                `protobuf:"fixed64,2,opt,name=speed,proto3" json:"speed,omitempty"`
+	WanderOnComplete bool                   `protobuf:"varint,3,opt,name=wander_on_complete,json=wanderOnComplete,proto3" json:"wander_on_complete,omitempty"`
+	Leg              uint32                 `protobuf:"varint,4,opt,name=leg,proto3" json:"leg,omitempty"`
+	TotalDistance    float64                `protobuf:"fixed64,5,opt,name=total_distance,json=totalDistance,proto3" json:"total_distance,omitempty"`
+	Travelled        float64                `protobuf:"fixed64,6,opt,name=travelled,proto3" json:"travelled,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MissionRecord) Reset() {
+	*x = MissionRecord{}
+	mi := &file_proto_machine_stream_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MissionRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MissionRecord) ProtoMessage() {}
+
+func (x *MissionRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MissionRecord.ProtoReflect.Descriptor instead.
+func (*MissionRecord) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *MissionRecord) GetWaypoints() []*GPS {
+	if x != nil {
+		return x.Waypoints
+	}
+	return nil
+}
+
+func (x *MissionRecord) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *MissionRecord) GetWanderOnComplete() bool {
+	if x != nil {
+		return x.WanderOnComplete
+	}
+	return false
+}
+
+func (x *MissionRecord) GetLeg() uint32 {
+	if x != nil {
+		return x.Leg
+	}
+	return 0
+}
+
+func (x *MissionRecord) GetTotalDistance() float64 {
+	if x != nil {
+		return x.TotalDistance
+	}
+	return 0
+}
+
+func (x *MissionRecord) GetTravelled() float64 {
+	if x != nil {
+		return x.Travelled
+	}
+	return 0
+}
+
+// A command appended to the write-ahead log once it has been applied
+type WALRecord struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Sequence uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Types that are valid to be assigned to Command:
+	//
+	//	*WALRecord_CreateMachine
+	//	*WALRecord_DeleteMachine
+	//	*WALRecord_Pause
+	//	*WALRecord_Unpause
+	//	*WALRecord_Refuel
+	//	*WALRecord_AssignMission
+	//	*WALRecord_CancelMission
+	//	*WALRecord_SetTimeScale
+	//	*WALRecord_PauseSimulation
+	//	*WALRecord_ResumeSimulation
+	//	*WALRecord_CreateGeofence
+	//	*WALRecord_DeleteGeofence
+	Command       isWALRecord_Command `protobuf_oneof:"command"`
+	MachineId     uint32              `protobuf:"varint,12,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"` // id assigned by create_machine
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WALRecord) Reset() {
+	*x = WALRecord{}
+	mi := &file_proto_machine_stream_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WALRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WALRecord) ProtoMessage() {}
+
+func (x *WALRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WALRecord.ProtoReflect.Descriptor instead.
+func (*WALRecord) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WALRecord) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *WALRecord) GetCommand() isWALRecord_Command {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *WALRecord) GetCreateMachine() *CreateMachineRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_CreateMachine); ok {
+			return x.CreateMachine
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetDeleteMachine() *Machine {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_DeleteMachine); ok {
+			return x.DeleteMachine
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetPause() *Machine {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_Pause); ok {
+			return x.Pause
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetUnpause() *Machine {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_Unpause); ok {
+			return x.Unpause
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetRefuel() *RefuelRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_Refuel); ok {
+			return x.Refuel
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetAssignMission() *AssignMissionRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_AssignMission); ok {
+			return x.AssignMission
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetCancelMission() *Machine {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_CancelMission); ok {
+			return x.CancelMission
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetSetTimeScale() *SetTimeScaleRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_SetTimeScale); ok {
+			return x.SetTimeScale
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetPauseSimulation() *PauseSimulationRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_PauseSimulation); ok {
+			return x.PauseSimulation
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetResumeSimulation() *ResumeSimulationRequest {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_ResumeSimulation); ok {
+			return x.ResumeSimulation
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetCreateGeofence() *Geofence {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_CreateGeofence); ok {
+			return x.CreateGeofence
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetDeleteGeofence() *Geofence {
+	if x != nil {
+		if x, ok := x.Command.(*WALRecord_DeleteGeofence); ok {
+			return x.DeleteGeofence
+		}
+	}
+	return nil
+}
+
+func (x *WALRecord) GetMachineId() uint32 {
+	if x != nil {
+		return x.MachineId
+	}
+	return 0
+}
+
+type isWALRecord_Command interface {
+	isWALRecord_Command()
+}
+
+type WALRecord_CreateMachine struct {
+	CreateMachine *CreateMachineRequest `protobuf:"bytes,2,opt,name=create_machine,json=createMachine,proto3,oneof"` // seed is always set
+}
+
+type WALRecord_DeleteMachine struct {
+	DeleteMachine *Machine `protobuf:"bytes,3,opt,name=delete_machine,json=deleteMachine,proto3,oneof"`
+}
+
+type WALRecord_Pause struct {
+	Pause *Machine `protobuf:"bytes,4,opt,name=pause,proto3,oneof"`
+}
+
+type WALRecord_Unpause struct {
+	Unpause *Machine `protobuf:"bytes,5,opt,name=unpause,proto3,oneof"`
+}
+
+type WALRecord_Refuel struct {
+	Refuel *RefuelRequest `protobuf:"bytes,6,opt,name=refuel,proto3,oneof"`
+}
+
+type WALRecord_AssignMission struct {
+	AssignMission *AssignMissionRequest `protobuf:"bytes,7,opt,name=assign_mission,json=assignMission,proto3,oneof"`
+}
+
+type WALRecord_CancelMission struct {
+	CancelMission *Machine `protobuf:"bytes,8,opt,name=cancel_mission,json=cancelMission,proto3,oneof"`
+}
+
+type WALRecord_SetTimeScale struct {
+	SetTimeScale *SetTimeScaleRequest `protobuf:"bytes,9,opt,name=set_time_scale,json=setTimeScale,proto3,oneof"`
+}
+
+type WALRecord_PauseSimulation struct {
+	PauseSimulation *PauseSimulationRequest `protobuf:"bytes,10,opt,name=pause_simulation,json=pauseSimulation,proto3,oneof"`
+}
+
+type WALRecord_ResumeSimulation struct {
+	ResumeSimulation *ResumeSimulationRequest `protobuf:"bytes,11,opt,name=resume_simulation,json=resumeSimulation,proto3,oneof"`
+}
+
+type WALRecord_CreateGeofence struct {
+	CreateGeofence *Geofence `protobuf:"bytes,13,opt,name=create_geofence,json=createGeofence,proto3,oneof"` // id is always set
+}
+
+type WALRecord_DeleteGeofence struct {
+	DeleteGeofence *Geofence `protobuf:"bytes,14,opt,name=delete_geofence,json=deleteGeofence,proto3,oneof"`
+}
+
+func (*WALRecord_CreateMachine) isWALRecord_Command() {}
+
+func (*WALRecord_DeleteMachine) isWALRecord_Command() {}
+
+func (*WALRecord_Pause) isWALRecord_Command() {}
+
+func (*WALRecord_Unpause) isWALRecord_Command() {}
+
+func (*WALRecord_Refuel) isWALRecord_Command() {}
+
+func (*WALRecord_AssignMission) isWALRecord_Command() {}
+
+func (*WALRecord_CancelMission) isWALRecord_Command() {}
+
+func (*WALRecord_SetTimeScale) isWALRecord_Command() {}
+
+func (*WALRecord_PauseSimulation) isWALRecord_Command() {}
+
+func (*WALRecord_ResumeSimulation) isWALRecord_Command() {}
+
+func (*WALRecord_CreateGeofence) isWALRecord_Command() {}
+
+func (*WALRecord_DeleteGeofence) isWALRecord_Command() {}
+
+// Downsampling options apply in order: min_interval first, then max_points
+type GetTrackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`                                // simulation time, unset for the oldest point kept
+	Until         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`                                // simulation time, unset for the latest point
+	MinInterval   *durationpb.Duration   `protobuf:"bytes,4,opt,name=min_interval,json=minInterval,proto3" json:"min_interval,omitempty"` // keep at most one point per interval, 0 keeps every point
+	MaxPoints     uint32                 `protobuf:"varint,5,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`      // evenly thin the track to at most this many points, 0 for no limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrackRequest) Reset() {
+	*x = GetTrackRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrackRequest) ProtoMessage() {}
+
+func (x *GetTrackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrackRequest.ProtoReflect.Descriptor instead.
+func (*GetTrackRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetTrackRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetTrackRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *GetTrackRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *GetTrackRequest) GetMinInterval() *durationpb.Duration {
+	if x != nil {
+		return x.MinInterval
+	}
+	return nil
+}
+
+func (x *GetTrackRequest) GetMaxPoints() uint32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+type GetTrackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Points        []*TrackPoint          `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrackResponse) Reset() {
+	*x = GetTrackResponse{}
+	mi := &file_proto_machine_stream_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrackResponse) ProtoMessage() {}
+
+func (x *GetTrackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrackResponse.ProtoReflect.Descriptor instead.
+func (*GetTrackResponse) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetTrackResponse) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetTrackResponse) GetPoints() []*TrackPoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+// A sample of a machine's position history. A point lasts until the next one, unchanged samples are not kept
+type TrackPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Location      *GPS                   `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	FuelLevel     float32                `protobuf:"fixed32,3,opt,name=fuel_level,json=fuelLevel,proto3" json:"fuel_level,omitempty"`
+	Status        MachineStatus          `protobuf:"varint,4,opt,name=status,proto3,enum=proto.MachineStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackPoint) Reset() {
+	*x = TrackPoint{}
+	mi := &file_proto_machine_stream_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackPoint) ProtoMessage() {}
+
+func (x *TrackPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackPoint.ProtoReflect.Descriptor instead.
+func (*TrackPoint) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TrackPoint) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *TrackPoint) GetLocation() *GPS {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *TrackPoint) GetFuelLevel() float32 {
+	if x != nil {
+		return x.FuelLevel
+	}
+	return 0
+}
+
+func (x *TrackPoint) GetStatus() MachineStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MachineStatus_IDLE
+}
+
+// A polygon zone machines must stay in or out of. Altitude is ignored
+type Geofence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // assigned by CreateGeofence
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type          GeofenceType           `protobuf:"varint,3,opt,name=type,proto3,enum=proto.GeofenceType" json:"type,omitempty"`
+	Ring          []*GPS                 `protobuf:"bytes,4,rep,name=ring,proto3" json:"ring,omitempty"` // at least three vertices, the ring closes itself
+	Action        GeofenceAction         `protobuf:"varint,5,opt,name=action,proto3,enum=proto.GeofenceAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Geofence) Reset() {
+	*x = Geofence{}
+	mi := &file_proto_machine_stream_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Geofence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Geofence) ProtoMessage() {}
+
+func (x *Geofence) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Geofence.ProtoReflect.Descriptor instead.
+func (*Geofence) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Geofence) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Geofence) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Geofence) GetType() GeofenceType {
+	if x != nil {
+		return x.Type
+	}
+	return GeofenceType_KEEP_OUT
+}
+
+func (x *Geofence) GetRing() []*GPS {
+	if x != nil {
+		return x.Ring
+	}
+	return nil
+}
+
+func (x *Geofence) GetAction() GeofenceAction {
+	if x != nil {
+		return x.Action
+	}
+	return GeofenceAction_NOTIFY
+}
+
+type ListGeofencesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGeofencesRequest) Reset() {
+	*x = ListGeofencesRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGeofencesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGeofencesRequest) ProtoMessage() {}
+
+func (x *ListGeofencesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGeofencesRequest.ProtoReflect.Descriptor instead.
+func (*ListGeofencesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{26}
+}
+
+type ListGeofencesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Geofences     []*Geofence            `protobuf:"bytes,1,rep,name=geofences,proto3" json:"geofences,omitempty"` // ordered by id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGeofencesResponse) Reset() {
+	*x = ListGeofencesResponse{}
+	mi := &file_proto_machine_stream_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGeofencesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGeofencesResponse) ProtoMessage() {}
+
+func (x *ListGeofencesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGeofencesResponse.ProtoReflect.Descriptor instead.
+func (*ListGeofencesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListGeofencesResponse) GetGeofences() []*Geofence {
+	if x != nil {
+		return x.Geofences
+	}
+	return nil
+}
+
+type WatchEventsRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	MachineId          uint32                 `protobuf:"varint,1,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"` // only events about this machine, every machine when 0
+	SlowConsumerPolicy SlowConsumerPolicy     `protobuf:"varint,2,opt,name=slow_consumer_policy,json=slowConsumerPolicy,proto3,enum=proto.SlowConsumerPolicy" json:"slow_consumer_policy,omitempty"`
+	MaxLag             uint32                 `protobuf:"varint,3,opt,name=max_lag,json=maxLag,proto3" json:"max_lag,omitempty"` // events queued for the client before the policy applies, server default when 0
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *WatchEventsRequest) GetMachineId() uint32 {
+	if x != nil {
+		return x.MachineId
+	}
+	return 0
+}
+
+func (x *WatchEventsRequest) GetSlowConsumerPolicy() SlowConsumerPolicy {
+	if x != nil {
+		return x.SlowConsumerPolicy
+	}
+	return SlowConsumerPolicy_SERVER_DEFAULT
+}
+
+func (x *WatchEventsRequest) GetMaxLag() uint32 {
+	if x != nil {
+		return x.MaxLag
+	}
+	return 0
+}
+
+// Something that happened to a machine, as opposed to its state
+type Event struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`              // increases with every event
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // simulation time
+	MachineId uint32                 `protobuf:"varint,3,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"`
+	// Types that are valid to be assigned to Detail:
+	//
+	//	*Event_GeofenceBreach
+	//	*Event_Alert
+	//	*Event_Proximity
+	Detail        isEvent_Detail `protobuf_oneof:"detail"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_proto_machine_stream_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Event) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Event) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Event) GetMachineId() uint32 {
+	if x != nil {
+		return x.MachineId
+	}
+	return 0
+}
+
+func (x *Event) GetDetail() isEvent_Detail {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+func (x *Event) GetGeofenceBreach() *GeofenceBreach {
+	if x != nil {
+		if x, ok := x.Detail.(*Event_GeofenceBreach); ok {
+			return x.GeofenceBreach
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetAlert() *Alert {
+	if x != nil {
+		if x, ok := x.Detail.(*Event_Alert); ok {
+			return x.Alert
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetProximity() *Proximity {
+	if x != nil {
+		if x, ok := x.Detail.(*Event_Proximity); ok {
+			return x.Proximity
+		}
+	}
+	return nil
+}
+
+type isEvent_Detail interface {
+	isEvent_Detail()
+}
+
+type Event_GeofenceBreach struct {
+	GeofenceBreach *GeofenceBreach `protobuf:"bytes,4,opt,name=geofence_breach,json=geofenceBreach,proto3,oneof"`
+}
+
+type Event_Alert struct {
+	Alert *Alert `protobuf:"bytes,5,opt,name=alert,proto3,oneof"` // raised, or cleared when cleared_at is set
+}
+
+type Event_Proximity struct {
+	Proximity *Proximity `protobuf:"bytes,6,opt,name=proximity,proto3,oneof"`
+}
+
+func (*Event_GeofenceBreach) isEvent_Detail() {}
+
+func (*Event_Alert) isEvent_Detail() {}
+
+func (*Event_Proximity) isEvent_Detail() {}
+
+// A machine crossed into a keep-out zone or out of a keep-in zone, or got back into compliance
+type GeofenceBreach struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GeofenceId    uint32                 `protobuf:"varint,1,opt,name=geofence_id,json=geofenceId,proto3" json:"geofence_id,omitempty"`
+	GeofenceName  string                 `protobuf:"bytes,2,opt,name=geofence_name,json=geofenceName,proto3" json:"geofence_name,omitempty"`
+	GeofenceType  GeofenceType           `protobuf:"varint,3,opt,name=geofence_type,json=geofenceType,proto3,enum=proto.GeofenceType" json:"geofence_type,omitempty"`
+	Action        GeofenceAction         `protobuf:"varint,4,opt,name=action,proto3,enum=proto.GeofenceAction" json:"action,omitempty"` // what the server did about it
+	Location      *GPS                   `protobuf:"bytes,5,opt,name=location,proto3" json:"location,omitempty"`                        // where the breach was detected
+	Cleared       bool                   `protobuf:"varint,6,opt,name=cleared,proto3" json:"cleared,omitempty"`                         // the machine is back in compliance
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeofenceBreach) Reset() {
+	*x = GeofenceBreach{}
+	mi := &file_proto_machine_stream_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeofenceBreach) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeofenceBreach) ProtoMessage() {}
+
+func (x *GeofenceBreach) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeofenceBreach.ProtoReflect.Descriptor instead.
+func (*GeofenceBreach) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *GeofenceBreach) GetGeofenceId() uint32 {
+	if x != nil {
+		return x.GeofenceId
+	}
+	return 0
+}
+
+func (x *GeofenceBreach) GetGeofenceName() string {
+	if x != nil {
+		return x.GeofenceName
+	}
+	return ""
+}
+
+func (x *GeofenceBreach) GetGeofenceType() GeofenceType {
+	if x != nil {
+		return x.GeofenceType
+	}
+	return GeofenceType_KEEP_OUT
+}
+
+func (x *GeofenceBreach) GetAction() GeofenceAction {
+	if x != nil {
+		return x.Action
+	}
+	return GeofenceAction_NOTIFY
+}
+
+func (x *GeofenceBreach) GetLocation() *GPS {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *GeofenceBreach) GetCleared() bool {
+	if x != nil {
+		return x.Cleared
+	}
+	return false
+}
+
+// The machine came within the separation distance of another one, or got clear of it again.
+// Each encounter is reported once for each machine of the pair
+type Proximity struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OtherMachineId uint32                 `protobuf:"varint,1,opt,name=other_machine_id,json=otherMachineId,proto3" json:"other_machine_id,omitempty"`
+	Distance       float64                `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"` // horizontal meters between the two
+	Location       *GPS                   `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`   // before any avoidance
+	OtherLocation  *GPS                   `protobuf:"bytes,4,opt,name=other_location,json=otherLocation,proto3" json:"other_location,omitempty"`
+	Action         AvoidancePolicy        `protobuf:"varint,5,opt,name=action,proto3,enum=proto.AvoidancePolicy" json:"action,omitempty"` // what the server did to this machine, the other one keeps going
+	Cleared        bool                   `protobuf:"varint,6,opt,name=cleared,proto3" json:"cleared,omitempty"`                          // the pair is apart again
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Proximity) Reset() {
+	*x = Proximity{}
+	mi := &file_proto_machine_stream_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Proximity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Proximity) ProtoMessage() {}
+
+func (x *Proximity) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Proximity.ProtoReflect.Descriptor instead.
+func (*Proximity) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Proximity) GetOtherMachineId() uint32 {
+	if x != nil {
+		return x.OtherMachineId
+	}
+	return 0
+}
+
+func (x *Proximity) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *Proximity) GetLocation() *GPS {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Proximity) GetOtherLocation() *GPS {
+	if x != nil {
+		return x.OtherLocation
+	}
+	return nil
+}
+
+func (x *Proximity) GetAction() AvoidancePolicy {
+	if x != nil {
+		return x.Action
+	}
+	return AvoidancePolicy_NO_AVOIDANCE
+}
+
+func (x *Proximity) GetCleared() bool {
+	if x != nil {
+		return x.Cleared
+	}
+	return false
+}
+
+// A rule that matched a machine. It stays active until the condition no longer holds
+type Alert struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Location      *GPS                   `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
-	FuelLevel     float32                `protobuf:"fixed32,3,opt,name=fuel_level,json=fuelLevel,proto3" json:"fuel_level,omitempty"`
-	IsPaused      bool                   `protobuf:"varint,4,opt,name=is_paused,json=isPaused,proto3" json:"is_paused,omitempty"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	MachineId     uint32                 `protobuf:"varint,2,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"`
+	Rule          AlertRule              `protobuf:"varint,3,opt,name=rule,proto3,enum=proto.AlertRule" json:"rule,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                      // e.g. "fuel at 18.5%, below 20%"
+	Value         float64                `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`                        // reading that raised the alert: fuel percent, seconds paused, ticks without movement or altitude
+	RaisedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=raised_at,json=raisedAt,proto3" json:"raised_at,omitempty"`    // simulation time
+	ClearedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=cleared_at,json=clearedAt,proto3" json:"cleared_at,omitempty"` // unset while the alert is active
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Machine) Reset() {
-	*x = Machine{}
-	mi := &file_proto_machine_stream_proto_msgTypes[0]
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_proto_machine_stream_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Machine) String() string {
+func (x *Alert) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Machine) ProtoMessage() {}
+func (*Alert) ProtoMessage() {}
 
-func (x *Machine) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_machine_stream_proto_msgTypes[0]
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,63 +2801,134 @@ func (x *Machine) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Machine.ProtoReflect.Descriptor instead.
-func (*Machine) Descriptor() ([]byte, []int) {
-	return file_proto_machine_stream_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *Machine) GetId() uint32 {
+func (x *Alert) GetId() uint64 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *Machine) GetLocation() *GPS {
+func (x *Alert) GetMachineId() uint32 {
 	if x != nil {
-		return x.Location
+		return x.MachineId
+	}
+	return 0
+}
+
+func (x *Alert) GetRule() AlertRule {
+	if x != nil {
+		return x.Rule
+	}
+	return AlertRule_LOW_FUEL
+}
+
+func (x *Alert) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Alert) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Alert) GetRaisedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RaisedAt
 	}
 	return nil
 }
 
-func (x *Machine) GetFuelLevel() float32 {
+func (x *Alert) GetClearedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.FuelLevel
+		return x.ClearedAt
+	}
+	return nil
+}
+
+type ListAlertsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MachineId      uint32                 `protobuf:"varint,1,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"`                // only alerts about this machine, every machine when 0
+	IncludeCleared bool                   `protobuf:"varint,2,opt,name=include_cleared,json=includeCleared,proto3" json:"include_cleared,omitempty"` // also return recently cleared alerts
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListAlertsRequest) Reset() {
+	*x = ListAlertsRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsRequest) ProtoMessage() {}
+
+func (x *ListAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsRequest.ProtoReflect.Descriptor instead.
+func (*ListAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListAlertsRequest) GetMachineId() uint32 {
+	if x != nil {
+		return x.MachineId
 	}
 	return 0
 }
 
-func (x *Machine) GetIsPaused() bool {
+func (x *ListAlertsRequest) GetIncludeCleared() bool {
 	if x != nil {
-		return x.IsPaused
+		return x.IncludeCleared
 	}
 	return false
 }
 
-type GPS struct {
+type ListAlertsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
-	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
-	Alt           float32                `protobuf:"fixed32,3,opt,name=alt,proto3" json:"alt,omitempty"`
+	Alerts        []*Alert               `protobuf:"bytes,1,rep,name=alerts,proto3" json:"alerts,omitempty"` // ordered by id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GPS) Reset() {
-	*x = GPS{}
-	mi := &file_proto_machine_stream_proto_msgTypes[1]
+func (x *ListAlertsResponse) Reset() {
+	*x = ListAlertsResponse{}
+	mi := &file_proto_machine_stream_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GPS) String() string {
+func (x *ListAlertsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GPS) ProtoMessage() {}
+func (*ListAlertsResponse) ProtoMessage() {}
 
-func (x *GPS) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_machine_stream_proto_msgTypes[1]
+func (x *ListAlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -123,53 +2939,201 @@ func (x *GPS) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GPS.ProtoReflect.Descriptor instead.
-func (*GPS) Descriptor() ([]byte, []int) {
-	return file_proto_machine_stream_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use ListAlertsResponse.ProtoReflect.Descriptor instead.
+func (*ListAlertsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *GPS) GetLat() float64 {
+func (x *ListAlertsResponse) GetAlerts() []*Alert {
 	if x != nil {
-		return x.Lat
+		return x.Alerts
+	}
+	return nil
+}
+
+type ListSubscribersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscribersRequest) Reset() {
+	*x = ListSubscribersRequest{}
+	mi := &file_proto_machine_stream_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscribersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscribersRequest) ProtoMessage() {}
+
+func (x *ListSubscribersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscribersRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscribersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{35}
+}
+
+// Delivery counters for one open MachineStream or WatchFleet stream
+type SubscriberStats struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Method             string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`                         // "MachineStream" or "WatchFleet"
+	MachineId          uint32                 `protobuf:"varint,3,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"` // 0 when watching the whole fleet
+	SlowConsumerPolicy SlowConsumerPolicy     `protobuf:"varint,4,opt,name=slow_consumer_policy,json=slowConsumerPolicy,proto3,enum=proto.SlowConsumerPolicy" json:"slow_consumer_policy,omitempty"`
+	MaxLag             uint32                 `protobuf:"varint,5,opt,name=max_lag,json=maxLag,proto3" json:"max_lag,omitempty"`
+	Lag                uint32                 `protobuf:"varint,6,opt,name=lag,proto3" json:"lag,omitempty"` // updates queued and not yet sent
+	PeakLag            uint32                 `protobuf:"varint,7,opt,name=peak_lag,json=peakLag,proto3" json:"peak_lag,omitempty"`
+	Sent               uint64                 `protobuf:"varint,8,opt,name=sent,proto3" json:"sent,omitempty"`
+	Dropped            uint64                 `protobuf:"varint,9,opt,name=dropped,proto3" json:"dropped,omitempty"`      // updates discarded by DROP_OLDEST
+	Coalesced          uint64                 `protobuf:"varint,10,opt,name=coalesced,proto3" json:"coalesced,omitempty"` // updates replaced by a newer one under COALESCE_LATEST
+	SubscribedAt       *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=subscribed_at,json=subscribedAt,proto3" json:"subscribed_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SubscriberStats) Reset() {
+	*x = SubscriberStats{}
+	mi := &file_proto_machine_stream_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriberStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriberStats) ProtoMessage() {}
+
+func (x *SubscriberStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriberStats.ProtoReflect.Descriptor instead.
+func (*SubscriberStats) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *SubscriberStats) GetId() uint64 {
+	if x != nil {
+		return x.Id
 	}
 	return 0
 }
 
-func (x *GPS) GetLon() float64 {
+func (x *SubscriberStats) GetMethod() string {
 	if x != nil {
-		return x.Lon
+		return x.Method
+	}
+	return ""
+}
+
+func (x *SubscriberStats) GetMachineId() uint32 {
+	if x != nil {
+		return x.MachineId
 	}
 	return 0
 }
 
-func (x *GPS) GetAlt() float32 {
+func (x *SubscriberStats) GetSlowConsumerPolicy() SlowConsumerPolicy {
 	if x != nil {
-		return x.Alt
+		return x.SlowConsumerPolicy
+	}
+	return SlowConsumerPolicy_SERVER_DEFAULT
+}
+
+func (x *SubscriberStats) GetMaxLag() uint32 {
+	if x != nil {
+		return x.MaxLag
 	}
 	return 0
 }
 
-type MachineStreamRequest struct {
+func (x *SubscriberStats) GetLag() uint32 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+func (x *SubscriberStats) GetPeakLag() uint32 {
+	if x != nil {
+		return x.PeakLag
+	}
+	return 0
+}
+
+func (x *SubscriberStats) GetSent() uint64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *SubscriberStats) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *SubscriberStats) GetCoalesced() uint64 {
+	if x != nil {
+		return x.Coalesced
+	}
+	return 0
+}
+
+func (x *SubscriberStats) GetSubscribedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SubscribedAt
+	}
+	return nil
+}
+
+type ListSubscribersResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscribers   []*SubscriberStats     `protobuf:"bytes,1,rep,name=subscribers,proto3" json:"subscribers,omitempty"` // ordered by id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MachineStreamRequest) Reset() {
-	*x = MachineStreamRequest{}
-	mi := &file_proto_machine_stream_proto_msgTypes[2]
+func (x *ListSubscribersResponse) Reset() {
+	*x = ListSubscribersResponse{}
+	mi := &file_proto_machine_stream_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MachineStreamRequest) String() string {
+func (x *ListSubscribersResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MachineStreamRequest) ProtoMessage() {}
+func (*ListSubscribersResponse) ProtoMessage() {}
 
-func (x *MachineStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_machine_stream_proto_msgTypes[2]
+func (x *ListSubscribersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_machine_stream_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -180,33 +3144,320 @@ func (x *MachineStreamRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MachineStreamRequest.ProtoReflect.Descriptor instead.
-func (*MachineStreamRequest) Descriptor() ([]byte, []int) {
-	return file_proto_machine_stream_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ListSubscribersResponse.ProtoReflect.Descriptor instead.
+func (*ListSubscribersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_machine_stream_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListSubscribersResponse) GetSubscribers() []*SubscriberStats {
+	if x != nil {
+		return x.Subscribers
+	}
+	return nil
 }
 
 var File_proto_machine_stream_proto protoreflect.FileDescriptor
 
 const file_proto_machine_stream_proto_rawDesc = "" +
 	"\n" +
-	"\x1aproto/machine_stream.proto\x12\x05proto\"}\n" +
+	"\x1aproto/machine_stream.proto\x12\x05proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb6\x03\n" +
 	"\aMachine\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\x12&\n" +
 	"\blocation\x18\x02 \x01(\v2\n" +
 	".proto.GPSR\blocation\x12\x1d\n" +
 	"\n" +
-	"fuel_level\x18\x03 \x01(\x02R\tfuelLevel\x12\x1b\n" +
-	"\tis_paused\x18\x04 \x01(\bR\bisPaused\";\n" +
+	"fuel_level\x18\x03 \x01(\x02R\tfuelLevel\x12\x1f\n" +
+	"\tis_paused\x18\x04 \x01(\bB\x02\x18\x01R\bisPaused\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x18\n" +
+	"\aheading\x18\x06 \x01(\x01R\aheading\x12\x14\n" +
+	"\x05speed\x18\a \x01(\x01R\x05speed\x12\x1a\n" +
+	"\bodometer\x18\b \x01(\x01R\bodometer\x12,\n" +
+	"\x06status\x18\t \x01(\x0e2\x14.proto.MachineStatusR\x06status\x120\n" +
+	"\amission\x18\n" +
+	" \x01(\v2\x16.proto.MissionProgressR\amission\x129\n" +
+	"\fmotion_model\x18\v \x01(\x0e2\x16.proto.MotionModelTypeR\vmotionModel\x12\x12\n" +
+	"\x04seed\x18\f \x01(\x04R\x04seed\"v\n" +
+	"\x0fMissionProgress\x12\x1b\n" +
+	"\tleg_index\x18\x01 \x01(\rR\blegIndex\x12\x1b\n" +
+	"\tleg_count\x18\x02 \x01(\rR\blegCount\x12)\n" +
+	"\x10percent_complete\x18\x03 \x01(\x01R\x0fpercentComplete\";\n" +
 	"\x03GPS\x12\x10\n" +
 	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
 	"\x03lon\x18\x02 \x01(\x01R\x03lon\x12\x10\n" +
-	"\x03alt\x18\x03 \x01(\x02R\x03alt\"\x16\n" +
-	"\x14MachineStreamRequest2\xa6\x01\n" +
+	"\x03alt\x18\x03 \x01(\x02R\x03alt\"\x8c\x01\n" +
+	"\x14MachineStreamRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12K\n" +
+	"\x14slow_consumer_policy\x18\x02 \x01(\x0e2\x19.proto.SlowConsumerPolicyR\x12slowConsumerPolicy\x12\x17\n" +
+	"\amax_lag\x18\x03 \x01(\rR\x06maxLag\"\xa3\x01\n" +
+	"\x14CreateMachineRequest\x129\n" +
+	"\fmotion_model\x18\x01 \x01(\x0e2\x16.proto.MotionModelTypeR\vmotionModel\x12\x14\n" +
+	"\x05speed\x18\x02 \x01(\x01R\x05speed\x12\x18\n" +
+	"\aheading\x18\x03 \x01(\x01R\aheading\x12\x17\n" +
+	"\x04seed\x18\x04 \x01(\x04H\x00R\x04seed\x88\x01\x01B\a\n" +
+	"\x05_seed\"3\n" +
+	"\rRefuelRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\x02R\x04rate\"\x94\x01\n" +
+	"\x14AssignMissionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12(\n" +
+	"\twaypoints\x18\x02 \x03(\v2\n" +
+	".proto.GPSR\twaypoints\x12\x14\n" +
+	"\x05speed\x18\x03 \x01(\x01R\x05speed\x12,\n" +
+	"\x12wander_on_complete\x18\x04 \x01(\bR\x10wanderOnComplete\"y\n" +
+	"\x11WatchFleetRequest\x12K\n" +
+	"\x14slow_consumer_policy\x18\x01 \x01(\x0e2\x19.proto.SlowConsumerPolicyR\x12slowConsumerPolicy\x12\x17\n" +
+	"\amax_lag\x18\x02 \x01(\rR\x06maxLag\"\x95\x01\n" +
+	"\x0fSimulationState\x12\x1d\n" +
+	"\n" +
+	"time_scale\x18\x01 \x01(\x01R\ttimeScale\x12\x16\n" +
+	"\x06paused\x18\x02 \x01(\bR\x06paused\x125\n" +
+	"\bsim_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\asimTime\x12\x14\n" +
+	"\x05ticks\x18\x04 \x01(\x04R\x05ticks\"4\n" +
+	"\x13SetTimeScaleRequest\x12\x1d\n" +
+	"\n" +
+	"time_scale\x18\x01 \x01(\x01R\ttimeScale\"\x18\n" +
+	"\x16PauseSimulationRequest\"\x19\n" +
+	"\x17ResumeSimulationRequest\"-\n" +
+	"\x15StepSimulationRequest\x12\x14\n" +
+	"\x05ticks\x18\x01 \x01(\rR\x05ticks\"q\n" +
+	"\vBoundingBox\x12\x17\n" +
+	"\amin_lat\x18\x01 \x01(\x01R\x06minLat\x12\x17\n" +
+	"\amin_lon\x18\x02 \x01(\x01R\x06minLon\x12\x17\n" +
+	"\amax_lat\x18\x03 \x01(\x01R\x06maxLat\x12\x17\n" +
+	"\amax_lon\x18\x04 \x01(\x01R\x06maxLon\"\xa9\x02\n" +
+	"\x13ListMachinesRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\rR\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12 \n" +
+	"\tis_paused\x18\x03 \x01(\bH\x00R\bisPaused\x88\x01\x01\x12)\n" +
+	"\x0emin_fuel_level\x18\x04 \x01(\x02H\x01R\fminFuelLevel\x88\x01\x01\x12)\n" +
+	"\x0emax_fuel_level\x18\x05 \x01(\x02H\x02R\fmaxFuelLevel\x88\x01\x01\x12*\n" +
+	"\x06bounds\x18\x06 \x01(\v2\x12.proto.BoundingBoxR\x06boundsB\f\n" +
+	"\n" +
+	"_is_pausedB\x11\n" +
+	"\x0f_min_fuel_levelB\x11\n" +
+	"\x0f_max_fuel_level\"j\n" +
+	"\x14ListMachinesResponse\x12*\n" +
+	"\bmachines\x18\x01 \x03(\v2\x0e.proto.MachineR\bmachines\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x9d\x01\n" +
+	"\n" +
+	"FleetEvent\x12*\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x16.proto.FleetEvent.TypeR\x04type\x12(\n" +
+	"\amachine\x18\x02 \x01(\v2\x0e.proto.MachineR\amachine\"9\n" +
+	"\x04Type\x12\f\n" +
+	"\bSNAPSHOT\x10\x00\x12\t\n" +
+	"\x05ADDED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aREMOVED\x10\x03\"8\n" +
+	"\vFleetUpdate\x12)\n" +
+	"\x06events\x18\x01 \x03(\v2\x11.proto.FleetEventR\x06events\"\x8b\x02\n" +
+	"\n" +
+	"FleetState\x12\x17\n" +
+	"\anext_id\x18\x01 \x01(\rR\x06nextId\x12!\n" +
+	"\fwal_sequence\x18\x02 \x01(\x04R\vwalSequence\x126\n" +
+	"\n" +
+	"simulation\x18\x03 \x01(\v2\x16.proto.SimulationStateR\n" +
+	"simulation\x120\n" +
+	"\bmachines\x18\x04 \x03(\v2\x14.proto.MachineRecordR\bmachines\x12-\n" +
+	"\tgeofences\x18\x05 \x03(\v2\x0f.proto.GeofenceR\tgeofences\x12(\n" +
+	"\x10next_geofence_id\x18\x06 \x01(\rR\x0enextGeofenceId\"\xbe\x02\n" +
+	"\rMachineRecord\x12(\n" +
+	"\amachine\x18\x01 \x01(\v2\x0e.proto.MachineR\amachine\x12\x1f\n" +
+	"\vrefuel_rate\x18\x02 \x01(\x02R\n" +
+	"refuelRate\x12\x1b\n" +
+	"\trng_state\x18\x03 \x01(\fR\brngState\x12!\n" +
+	"\fmotion_speed\x18\x04 \x01(\x01R\vmotionSpeed\x12%\n" +
+	"\x0emotion_heading\x18\x05 \x01(\x01R\rmotionHeading\x12+\n" +
+	"\vmotion_home\x18\x06 \x01(\v2\n" +
+	".proto.GPSR\n" +
+	"motionHome\x12.\n" +
+	"\amission\x18\a \x01(\v2\x14.proto.MissionRecordR\amission\x12\x1e\n" +
+	"\x04home\x18\b \x01(\v2\n" +
+	".proto.GPSR\x04home\"\xd4\x01\n" +
+	"\rMissionRecord\x12(\n" +
+	"\twaypoints\x18\x01 \x03(\v2\n" +
+	".proto.GPSR\twaypoints\x12\x14\n" +
+	"\x05speed\x18\x02 \x01(\x01R\x05speed\x12,\n" +
+	"\x12wander_on_complete\x18\x03 \x01(\bR\x10wanderOnComplete\x12\x10\n" +
+	"\x03leg\x18\x04 \x01(\rR\x03leg\x12%\n" +
+	"\x0etotal_distance\x18\x05 \x01(\x01R\rtotalDistance\x12\x1c\n" +
+	"\ttravelled\x18\x06 \x01(\x01R\ttravelled\"\xaa\x06\n" +
+	"\tWALRecord\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12D\n" +
+	"\x0ecreate_machine\x18\x02 \x01(\v2\x1b.proto.CreateMachineRequestH\x00R\rcreateMachine\x127\n" +
+	"\x0edelete_machine\x18\x03 \x01(\v2\x0e.proto.MachineH\x00R\rdeleteMachine\x12&\n" +
+	"\x05pause\x18\x04 \x01(\v2\x0e.proto.MachineH\x00R\x05pause\x12*\n" +
+	"\aunpause\x18\x05 \x01(\v2\x0e.proto.MachineH\x00R\aunpause\x12.\n" +
+	"\x06refuel\x18\x06 \x01(\v2\x14.proto.RefuelRequestH\x00R\x06refuel\x12D\n" +
+	"\x0eassign_mission\x18\a \x01(\v2\x1b.proto.AssignMissionRequestH\x00R\rassignMission\x127\n" +
+	"\x0ecancel_mission\x18\b \x01(\v2\x0e.proto.MachineH\x00R\rcancelMission\x12B\n" +
+	"\x0eset_time_scale\x18\t \x01(\v2\x1a.proto.SetTimeScaleRequestH\x00R\fsetTimeScale\x12J\n" +
+	"\x10pause_simulation\x18\n" +
+	" \x01(\v2\x1d.proto.PauseSimulationRequestH\x00R\x0fpauseSimulation\x12M\n" +
+	"\x11resume_simulation\x18\v \x01(\v2\x1e.proto.ResumeSimulationRequestH\x00R\x10resumeSimulation\x12:\n" +
+	"\x0fcreate_geofence\x18\r \x01(\v2\x0f.proto.GeofenceH\x00R\x0ecreateGeofence\x12:\n" +
+	"\x0fdelete_geofence\x18\x0e \x01(\v2\x0f.proto.GeofenceH\x00R\x0edeleteGeofence\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\f \x01(\rR\tmachineIdB\t\n" +
+	"\acommand\"\xe2\x01\n" +
+	"\x0fGetTrackRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x120\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12<\n" +
+	"\fmin_interval\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\vminInterval\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x05 \x01(\rR\tmaxPoints\"M\n" +
+	"\x10GetTrackResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12)\n" +
+	"\x06points\x18\x02 \x03(\v2\x11.proto.TrackPointR\x06points\"\xbb\x01\n" +
+	"\n" +
+	"TrackPoint\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12&\n" +
+	"\blocation\x18\x02 \x01(\v2\n" +
+	".proto.GPSR\blocation\x12\x1d\n" +
+	"\n" +
+	"fuel_level\x18\x03 \x01(\x02R\tfuelLevel\x12,\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x14.proto.MachineStatusR\x06status\"\xa6\x01\n" +
+	"\bGeofence\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12'\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x13.proto.GeofenceTypeR\x04type\x12\x1e\n" +
+	"\x04ring\x18\x04 \x03(\v2\n" +
+	".proto.GPSR\x04ring\x12-\n" +
+	"\x06action\x18\x05 \x01(\x0e2\x15.proto.GeofenceActionR\x06action\"\x16\n" +
+	"\x14ListGeofencesRequest\"F\n" +
+	"\x15ListGeofencesResponse\x12-\n" +
+	"\tgeofences\x18\x01 \x03(\v2\x0f.proto.GeofenceR\tgeofences\"\x99\x01\n" +
+	"\x12WatchEventsRequest\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\x01 \x01(\rR\tmachineId\x12K\n" +
+	"\x14slow_consumer_policy\x18\x02 \x01(\x0e2\x19.proto.SlowConsumerPolicyR\x12slowConsumerPolicy\x12\x17\n" +
+	"\amax_lag\x18\x03 \x01(\rR\x06maxLag\"\x94\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
 	"\n" +
-	"MachineMap\x12)\n" +
+	"machine_id\x18\x03 \x01(\rR\tmachineId\x12@\n" +
+	"\x0fgeofence_breach\x18\x04 \x01(\v2\x15.proto.GeofenceBreachH\x00R\x0egeofenceBreach\x12$\n" +
+	"\x05alert\x18\x05 \x01(\v2\f.proto.AlertH\x00R\x05alert\x120\n" +
+	"\tproximity\x18\x06 \x01(\v2\x10.proto.ProximityH\x00R\tproximityB\b\n" +
+	"\x06detail\"\x81\x02\n" +
+	"\x0eGeofenceBreach\x12\x1f\n" +
+	"\vgeofence_id\x18\x01 \x01(\rR\n" +
+	"geofenceId\x12#\n" +
+	"\rgeofence_name\x18\x02 \x01(\tR\fgeofenceName\x128\n" +
+	"\rgeofence_type\x18\x03 \x01(\x0e2\x13.proto.GeofenceTypeR\fgeofenceType\x12-\n" +
+	"\x06action\x18\x04 \x01(\x0e2\x15.proto.GeofenceActionR\x06action\x12&\n" +
+	"\blocation\x18\x05 \x01(\v2\n" +
+	".proto.GPSR\blocation\x12\x18\n" +
+	"\acleared\x18\x06 \x01(\bR\acleared\"\xf6\x01\n" +
+	"\tProximity\x12(\n" +
+	"\x10other_machine_id\x18\x01 \x01(\rR\x0eotherMachineId\x12\x1a\n" +
+	"\bdistance\x18\x02 \x01(\x01R\bdistance\x12&\n" +
+	"\blocation\x18\x03 \x01(\v2\n" +
+	".proto.GPSR\blocation\x121\n" +
+	"\x0eother_location\x18\x04 \x01(\v2\n" +
+	".proto.GPSR\rotherLocation\x12.\n" +
+	"\x06action\x18\x05 \x01(\x0e2\x16.proto.AvoidancePolicyR\x06action\x12\x18\n" +
+	"\acleared\x18\x06 \x01(\bR\acleared\"\x80\x02\n" +
+	"\x05Alert\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\x02 \x01(\rR\tmachineId\x12$\n" +
+	"\x04rule\x18\x03 \x01(\x0e2\x10.proto.AlertRuleR\x04rule\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\x01R\x05value\x127\n" +
+	"\traised_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\braisedAt\x129\n" +
+	"\n" +
+	"cleared_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tclearedAt\"[\n" +
+	"\x11ListAlertsRequest\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\x01 \x01(\rR\tmachineId\x12'\n" +
+	"\x0finclude_cleared\x18\x02 \x01(\bR\x0eincludeCleared\":\n" +
+	"\x12ListAlertsResponse\x12$\n" +
+	"\x06alerts\x18\x01 \x03(\v2\f.proto.AlertR\x06alerts\"\x18\n" +
+	"\x16ListSubscribersRequest\"\xf8\x02\n" +
+	"\x0fSubscriberStats\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\x03 \x01(\rR\tmachineId\x12K\n" +
+	"\x14slow_consumer_policy\x18\x04 \x01(\x0e2\x19.proto.SlowConsumerPolicyR\x12slowConsumerPolicy\x12\x17\n" +
+	"\amax_lag\x18\x05 \x01(\rR\x06maxLag\x12\x10\n" +
+	"\x03lag\x18\x06 \x01(\rR\x03lag\x12\x19\n" +
+	"\bpeak_lag\x18\a \x01(\rR\apeakLag\x12\x12\n" +
+	"\x04sent\x18\b \x01(\x04R\x04sent\x12\x18\n" +
+	"\adropped\x18\t \x01(\x04R\adropped\x12\x1c\n" +
+	"\tcoalesced\x18\n" +
+	" \x01(\x04R\tcoalesced\x12?\n" +
+	"\rsubscribed_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\fsubscribedAt\"S\n" +
+	"\x17ListSubscribersResponse\x128\n" +
+	"\vsubscribers\x18\x01 \x03(\v2\x16.proto.SubscriberStatsR\vsubscribers*P\n" +
+	"\rMachineStatus\x12\b\n" +
+	"\x04IDLE\x10\x00\x12\n" +
+	"\n" +
+	"\x06MOVING\x10\x01\x12\x0f\n" +
+	"\vOUT_OF_FUEL\x10\x02\x12\r\n" +
+	"\tRETURNING\x10\x03\x12\t\n" +
+	"\x05FAULT\x10\x04*g\n" +
+	"\x0fMotionModelType\x12\f\n" +
+	"\bBROWNIAN\x10\x00\x12\x1a\n" +
+	"\x16CORRELATED_RANDOM_WALK\x10\x01\x12\x16\n" +
+	"\x12ORNSTEIN_UHLENBECK\x10\x02\x12\x12\n" +
+	"\x0eDEAD_RECKONING\x10\x03*^\n" +
+	"\x12SlowConsumerPolicy\x12\x12\n" +
+	"\x0eSERVER_DEFAULT\x10\x00\x12\x0f\n" +
+	"\vDROP_OLDEST\x10\x01\x12\x13\n" +
+	"\x0fCOALESCE_LATEST\x10\x02\x12\x0e\n" +
+	"\n" +
+	"DISCONNECT\x10\x03*)\n" +
+	"\fGeofenceType\x12\f\n" +
+	"\bKEEP_OUT\x10\x00\x12\v\n" +
+	"\aKEEP_IN\x10\x01*8\n" +
+	"\x0eGeofenceAction\x12\n" +
+	"\n" +
+	"\x06NOTIFY\x10\x00\x12\x0e\n" +
+	"\n" +
+	"AUTO_PAUSE\x10\x01\x12\n" +
+	"\n" +
+	"\x06BOUNCE\x10\x02*C\n" +
+	"\x0fAvoidancePolicy\x12\x10\n" +
+	"\fNO_AVOIDANCE\x10\x00\x12\x0f\n" +
+	"\vNUDGE_APART\x10\x01\x12\r\n" +
+	"\tPAUSE_ONE\x10\x02*Y\n" +
+	"\tAlertRule\x12\f\n" +
+	"\bLOW_FUEL\x10\x00\x12\x13\n" +
+	"\x0fPAUSED_TOO_LONG\x10\x01\x12\x0f\n" +
+	"\vNO_MOVEMENT\x10\x02\x12\x18\n" +
+	"\x14ALTITUDE_OUT_OF_BAND\x10\x032\xf1\n" +
+	"\n" +
+	"\n" +
+	"MachineMap\x12>\n" +
+	"\rCreateMachine\x12\x1b.proto.CreateMachineRequest\x1a\x0e.proto.Machine\"\x00\x121\n" +
+	"\rDeleteMachine\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x12.\n" +
+	"\n" +
+	"GetMachine\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x12I\n" +
+	"\fListMachines\x12\x1a.proto.ListMachinesRequest\x1a\x1b.proto.ListMachinesResponse\"\x00\x12=\n" +
+	"\bGetTrack\x12\x16.proto.GetTrackRequest\x1a\x17.proto.GetTrackResponse\"\x00\x12)\n" +
 	"\x05Pause\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x12+\n" +
-	"\aUnPause\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x12@\n" +
-	"\rMachineStream\x12\x1b.proto.MachineStreamRequest\x1a\x0e.proto.Machine\"\x000\x01B\tZ\a./protob\x06proto3"
+	"\aUnPause\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x120\n" +
+	"\x06Refuel\x12\x14.proto.RefuelRequest\x1a\x0e.proto.Machine\"\x00\x12>\n" +
+	"\rAssignMission\x12\x1b.proto.AssignMissionRequest\x1a\x0e.proto.Machine\"\x00\x121\n" +
+	"\rCancelMission\x12\x0e.proto.Machine\x1a\x0e.proto.Machine\"\x00\x12@\n" +
+	"\rMachineStream\x12\x1b.proto.MachineStreamRequest\x1a\x0e.proto.Machine\"\x000\x01\x12>\n" +
+	"\n" +
+	"WatchFleet\x12\x18.proto.WatchFleetRequest\x1a\x12.proto.FleetUpdate\"\x000\x01\x12R\n" +
+	"\x0fListSubscribers\x12\x1d.proto.ListSubscribersRequest\x1a\x1e.proto.ListSubscribersResponse\"\x00\x124\n" +
+	"\x0eCreateGeofence\x12\x0f.proto.Geofence\x1a\x0f.proto.Geofence\"\x00\x124\n" +
+	"\x0eDeleteGeofence\x12\x0f.proto.Geofence\x1a\x0f.proto.Geofence\"\x00\x12L\n" +
+	"\rListGeofences\x12\x1b.proto.ListGeofencesRequest\x1a\x1c.proto.ListGeofencesResponse\"\x00\x12:\n" +
+	"\vWatchEvents\x12\x19.proto.WatchEventsRequest\x1a\f.proto.Event\"\x000\x01\x12C\n" +
+	"\n" +
+	"ListAlerts\x12\x18.proto.ListAlertsRequest\x1a\x19.proto.ListAlertsResponse\"\x00\x12D\n" +
+	"\fSetTimeScale\x12\x1a.proto.SetTimeScaleRequest\x1a\x16.proto.SimulationState\"\x00\x12J\n" +
+	"\x0fPauseSimulation\x12\x1d.proto.PauseSimulationRequest\x1a\x16.proto.SimulationState\"\x00\x12L\n" +
+	"\x10ResumeSimulation\x12\x1e.proto.ResumeSimulationRequest\x1a\x16.proto.SimulationState\"\x00\x12H\n" +
+	"\x0eStepSimulation\x12\x1c.proto.StepSimulationRequest\x1a\x16.proto.SimulationState\"\x00B\tZ\a./protob\x06proto3"
 
 var (
 	file_proto_machine_stream_proto_rawDescOnce sync.Once
@@ -220,25 +3471,172 @@ func file_proto_machine_stream_proto_rawDescGZIP() []byte {
 	return file_proto_machine_stream_proto_rawDescData
 }
 
-var file_proto_machine_stream_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_machine_stream_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_proto_machine_stream_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_proto_machine_stream_proto_goTypes = []any{
-	(*Machine)(nil),              // 0: proto.Machine
-	(*GPS)(nil),                  // 1: proto.GPS
-	(*MachineStreamRequest)(nil), // 2: proto.MachineStreamRequest
+	(MachineStatus)(0),              // 0: proto.MachineStatus
+	(MotionModelType)(0),            // 1: proto.MotionModelType
+	(SlowConsumerPolicy)(0),         // 2: proto.SlowConsumerPolicy
+	(GeofenceType)(0),               // 3: proto.GeofenceType
+	(GeofenceAction)(0),             // 4: proto.GeofenceAction
+	(AvoidancePolicy)(0),            // 5: proto.AvoidancePolicy
+	(AlertRule)(0),                  // 6: proto.AlertRule
+	(FleetEvent_Type)(0),            // 7: proto.FleetEvent.Type
+	(*Machine)(nil),                 // 8: proto.Machine
+	(*MissionProgress)(nil),         // 9: proto.MissionProgress
+	(*GPS)(nil),                     // 10: proto.GPS
+	(*MachineStreamRequest)(nil),    // 11: proto.MachineStreamRequest
+	(*CreateMachineRequest)(nil),    // 12: proto.CreateMachineRequest
+	(*RefuelRequest)(nil),           // 13: proto.RefuelRequest
+	(*AssignMissionRequest)(nil),    // 14: proto.AssignMissionRequest
+	(*WatchFleetRequest)(nil),       // 15: proto.WatchFleetRequest
+	(*SimulationState)(nil),         // 16: proto.SimulationState
+	(*SetTimeScaleRequest)(nil),     // 17: proto.SetTimeScaleRequest
+	(*PauseSimulationRequest)(nil),  // 18: proto.PauseSimulationRequest
+	(*ResumeSimulationRequest)(nil), // 19: proto.ResumeSimulationRequest
+	(*StepSimulationRequest)(nil),   // 20: proto.StepSimulationRequest
+	(*BoundingBox)(nil),             // 21: proto.BoundingBox
+	(*ListMachinesRequest)(nil),     // 22: proto.ListMachinesRequest
+	(*ListMachinesResponse)(nil),    // 23: proto.ListMachinesResponse
+	(*FleetEvent)(nil),              // 24: proto.FleetEvent
+	(*FleetUpdate)(nil),             // 25: proto.FleetUpdate
+	(*FleetState)(nil),              // 26: proto.FleetState
+	(*MachineRecord)(nil),           // 27: proto.MachineRecord
+	(*MissionRecord)(nil),           // 28: proto.MissionRecord
+	(*WALRecord)(nil),               // 29: proto.WALRecord
+	(*GetTrackRequest)(nil),         // 30: proto.GetTrackRequest
+	(*GetTrackResponse)(nil),        // 31: proto.GetTrackResponse
+	(*TrackPoint)(nil),              // 32: proto.TrackPoint
+	(*Geofence)(nil),                // 33: proto.Geofence
+	(*ListGeofencesRequest)(nil),    // 34: proto.ListGeofencesRequest
+	(*ListGeofencesResponse)(nil),   // 35: proto.ListGeofencesResponse
+	(*WatchEventsRequest)(nil),      // 36: proto.WatchEventsRequest
+	(*Event)(nil),                   // 37: proto.Event
+	(*GeofenceBreach)(nil),          // 38: proto.GeofenceBreach
+	(*Proximity)(nil),               // 39: proto.Proximity
+	(*Alert)(nil),                   // 40: proto.Alert
+	(*ListAlertsRequest)(nil),       // 41: proto.ListAlertsRequest
+	(*ListAlertsResponse)(nil),      // 42: proto.ListAlertsResponse
+	(*ListSubscribersRequest)(nil),  // 43: proto.ListSubscribersRequest
+	(*SubscriberStats)(nil),         // 44: proto.SubscriberStats
+	(*ListSubscribersResponse)(nil), // 45: proto.ListSubscribersResponse
+	(*timestamppb.Timestamp)(nil),   // 46: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),     // 47: google.protobuf.Duration
 }
 var file_proto_machine_stream_proto_depIdxs = []int32{
-	1, // 0: proto.Machine.location:type_name -> proto.GPS
-	0, // 1: proto.MachineMap.Pause:input_type -> proto.Machine
-	0, // 2: proto.MachineMap.UnPause:input_type -> proto.Machine
-	2, // 3: proto.MachineMap.MachineStream:input_type -> proto.MachineStreamRequest
-	0, // 4: proto.MachineMap.Pause:output_type -> proto.Machine
-	0, // 5: proto.MachineMap.UnPause:output_type -> proto.Machine
-	0, // 6: proto.MachineMap.MachineStream:output_type -> proto.Machine
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	10, // 0: proto.Machine.location:type_name -> proto.GPS
+	46, // 1: proto.Machine.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 2: proto.Machine.status:type_name -> proto.MachineStatus
+	9,  // 3: proto.Machine.mission:type_name -> proto.MissionProgress
+	1,  // 4: proto.Machine.motion_model:type_name -> proto.MotionModelType
+	2,  // 5: proto.MachineStreamRequest.slow_consumer_policy:type_name -> proto.SlowConsumerPolicy
+	1,  // 6: proto.CreateMachineRequest.motion_model:type_name -> proto.MotionModelType
+	10, // 7: proto.AssignMissionRequest.waypoints:type_name -> proto.GPS
+	2,  // 8: proto.WatchFleetRequest.slow_consumer_policy:type_name -> proto.SlowConsumerPolicy
+	46, // 9: proto.SimulationState.sim_time:type_name -> google.protobuf.Timestamp
+	21, // 10: proto.ListMachinesRequest.bounds:type_name -> proto.BoundingBox
+	8,  // 11: proto.ListMachinesResponse.machines:type_name -> proto.Machine
+	7,  // 12: proto.FleetEvent.type:type_name -> proto.FleetEvent.Type
+	8,  // 13: proto.FleetEvent.machine:type_name -> proto.Machine
+	24, // 14: proto.FleetUpdate.events:type_name -> proto.FleetEvent
+	16, // 15: proto.FleetState.simulation:type_name -> proto.SimulationState
+	27, // 16: proto.FleetState.machines:type_name -> proto.MachineRecord
+	33, // 17: proto.FleetState.geofences:type_name -> proto.Geofence
+	8,  // 18: proto.MachineRecord.machine:type_name -> proto.Machine
+	10, // 19: proto.MachineRecord.motion_home:type_name -> proto.GPS
+	28, // 20: proto.MachineRecord.mission:type_name -> proto.MissionRecord
+	10, // 21: proto.MachineRecord.home:type_name -> proto.GPS
+	10, // 22: proto.MissionRecord.waypoints:type_name -> proto.GPS
+	12, // 23: proto.WALRecord.create_machine:type_name -> proto.CreateMachineRequest
+	8,  // 24: proto.WALRecord.delete_machine:type_name -> proto.Machine
+	8,  // 25: proto.WALRecord.pause:type_name -> proto.Machine
+	8,  // 26: proto.WALRecord.unpause:type_name -> proto.Machine
+	13, // 27: proto.WALRecord.refuel:type_name -> proto.RefuelRequest
+	14, // 28: proto.WALRecord.assign_mission:type_name -> proto.AssignMissionRequest
+	8,  // 29: proto.WALRecord.cancel_mission:type_name -> proto.Machine
+	17, // 30: proto.WALRecord.set_time_scale:type_name -> proto.SetTimeScaleRequest
+	18, // 31: proto.WALRecord.pause_simulation:type_name -> proto.PauseSimulationRequest
+	19, // 32: proto.WALRecord.resume_simulation:type_name -> proto.ResumeSimulationRequest
+	33, // 33: proto.WALRecord.create_geofence:type_name -> proto.Geofence
+	33, // 34: proto.WALRecord.delete_geofence:type_name -> proto.Geofence
+	46, // 35: proto.GetTrackRequest.since:type_name -> google.protobuf.Timestamp
+	46, // 36: proto.GetTrackRequest.until:type_name -> google.protobuf.Timestamp
+	47, // 37: proto.GetTrackRequest.min_interval:type_name -> google.protobuf.Duration
+	32, // 38: proto.GetTrackResponse.points:type_name -> proto.TrackPoint
+	46, // 39: proto.TrackPoint.timestamp:type_name -> google.protobuf.Timestamp
+	10, // 40: proto.TrackPoint.location:type_name -> proto.GPS
+	0,  // 41: proto.TrackPoint.status:type_name -> proto.MachineStatus
+	3,  // 42: proto.Geofence.type:type_name -> proto.GeofenceType
+	10, // 43: proto.Geofence.ring:type_name -> proto.GPS
+	4,  // 44: proto.Geofence.action:type_name -> proto.GeofenceAction
+	33, // 45: proto.ListGeofencesResponse.geofences:type_name -> proto.Geofence
+	2,  // 46: proto.WatchEventsRequest.slow_consumer_policy:type_name -> proto.SlowConsumerPolicy
+	46, // 47: proto.Event.timestamp:type_name -> google.protobuf.Timestamp
+	38, // 48: proto.Event.geofence_breach:type_name -> proto.GeofenceBreach
+	40, // 49: proto.Event.alert:type_name -> proto.Alert
+	39, // 50: proto.Event.proximity:type_name -> proto.Proximity
+	3,  // 51: proto.GeofenceBreach.geofence_type:type_name -> proto.GeofenceType
+	4,  // 52: proto.GeofenceBreach.action:type_name -> proto.GeofenceAction
+	10, // 53: proto.GeofenceBreach.location:type_name -> proto.GPS
+	10, // 54: proto.Proximity.location:type_name -> proto.GPS
+	10, // 55: proto.Proximity.other_location:type_name -> proto.GPS
+	5,  // 56: proto.Proximity.action:type_name -> proto.AvoidancePolicy
+	6,  // 57: proto.Alert.rule:type_name -> proto.AlertRule
+	46, // 58: proto.Alert.raised_at:type_name -> google.protobuf.Timestamp
+	46, // 59: proto.Alert.cleared_at:type_name -> google.protobuf.Timestamp
+	40, // 60: proto.ListAlertsResponse.alerts:type_name -> proto.Alert
+	2,  // 61: proto.SubscriberStats.slow_consumer_policy:type_name -> proto.SlowConsumerPolicy
+	46, // 62: proto.SubscriberStats.subscribed_at:type_name -> google.protobuf.Timestamp
+	44, // 63: proto.ListSubscribersResponse.subscribers:type_name -> proto.SubscriberStats
+	12, // 64: proto.MachineMap.CreateMachine:input_type -> proto.CreateMachineRequest
+	8,  // 65: proto.MachineMap.DeleteMachine:input_type -> proto.Machine
+	8,  // 66: proto.MachineMap.GetMachine:input_type -> proto.Machine
+	22, // 67: proto.MachineMap.ListMachines:input_type -> proto.ListMachinesRequest
+	30, // 68: proto.MachineMap.GetTrack:input_type -> proto.GetTrackRequest
+	8,  // 69: proto.MachineMap.Pause:input_type -> proto.Machine
+	8,  // 70: proto.MachineMap.UnPause:input_type -> proto.Machine
+	13, // 71: proto.MachineMap.Refuel:input_type -> proto.RefuelRequest
+	14, // 72: proto.MachineMap.AssignMission:input_type -> proto.AssignMissionRequest
+	8,  // 73: proto.MachineMap.CancelMission:input_type -> proto.Machine
+	11, // 74: proto.MachineMap.MachineStream:input_type -> proto.MachineStreamRequest
+	15, // 75: proto.MachineMap.WatchFleet:input_type -> proto.WatchFleetRequest
+	43, // 76: proto.MachineMap.ListSubscribers:input_type -> proto.ListSubscribersRequest
+	33, // 77: proto.MachineMap.CreateGeofence:input_type -> proto.Geofence
+	33, // 78: proto.MachineMap.DeleteGeofence:input_type -> proto.Geofence
+	34, // 79: proto.MachineMap.ListGeofences:input_type -> proto.ListGeofencesRequest
+	36, // 80: proto.MachineMap.WatchEvents:input_type -> proto.WatchEventsRequest
+	41, // 81: proto.MachineMap.ListAlerts:input_type -> proto.ListAlertsRequest
+	17, // 82: proto.MachineMap.SetTimeScale:input_type -> proto.SetTimeScaleRequest
+	18, // 83: proto.MachineMap.PauseSimulation:input_type -> proto.PauseSimulationRequest
+	19, // 84: proto.MachineMap.ResumeSimulation:input_type -> proto.ResumeSimulationRequest
+	20, // 85: proto.MachineMap.StepSimulation:input_type -> proto.StepSimulationRequest
+	8,  // 86: proto.MachineMap.CreateMachine:output_type -> proto.Machine
+	8,  // 87: proto.MachineMap.DeleteMachine:output_type -> proto.Machine
+	8,  // 88: proto.MachineMap.GetMachine:output_type -> proto.Machine
+	23, // 89: proto.MachineMap.ListMachines:output_type -> proto.ListMachinesResponse
+	31, // 90: proto.MachineMap.GetTrack:output_type -> proto.GetTrackResponse
+	8,  // 91: proto.MachineMap.Pause:output_type -> proto.Machine
+	8,  // 92: proto.MachineMap.UnPause:output_type -> proto.Machine
+	8,  // 93: proto.MachineMap.Refuel:output_type -> proto.Machine
+	8,  // 94: proto.MachineMap.AssignMission:output_type -> proto.Machine
+	8,  // 95: proto.MachineMap.CancelMission:output_type -> proto.Machine
+	8,  // 96: proto.MachineMap.MachineStream:output_type -> proto.Machine
+	25, // 97: proto.MachineMap.WatchFleet:output_type -> proto.FleetUpdate
+	45, // 98: proto.MachineMap.ListSubscribers:output_type -> proto.ListSubscribersResponse
+	33, // 99: proto.MachineMap.CreateGeofence:output_type -> proto.Geofence
+	33, // 100: proto.MachineMap.DeleteGeofence:output_type -> proto.Geofence
+	35, // 101: proto.MachineMap.ListGeofences:output_type -> proto.ListGeofencesResponse
+	37, // 102: proto.MachineMap.WatchEvents:output_type -> proto.Event
+	42, // 103: proto.MachineMap.ListAlerts:output_type -> proto.ListAlertsResponse
+	16, // 104: proto.MachineMap.SetTimeScale:output_type -> proto.SimulationState
+	16, // 105: proto.MachineMap.PauseSimulation:output_type -> proto.SimulationState
+	16, // 106: proto.MachineMap.ResumeSimulation:output_type -> proto.SimulationState
+	16, // 107: proto.MachineMap.StepSimulation:output_type -> proto.SimulationState
+	86, // [86:108] is the sub-list for method output_type
+	64, // [64:86] is the sub-list for method input_type
+	64, // [64:64] is the sub-list for extension type_name
+	64, // [64:64] is the sub-list for extension extendee
+	0,  // [0:64] is the sub-list for field type_name
 }
 
 func init() { file_proto_machine_stream_proto_init() }
@@ -246,18 +3644,40 @@ func file_proto_machine_stream_proto_init() {
 	if File_proto_machine_stream_proto != nil {
 		return
 	}
+	file_proto_machine_stream_proto_msgTypes[4].OneofWrappers = []any{}
+	file_proto_machine_stream_proto_msgTypes[14].OneofWrappers = []any{}
+	file_proto_machine_stream_proto_msgTypes[21].OneofWrappers = []any{
+		(*WALRecord_CreateMachine)(nil),
+		(*WALRecord_DeleteMachine)(nil),
+		(*WALRecord_Pause)(nil),
+		(*WALRecord_Unpause)(nil),
+		(*WALRecord_Refuel)(nil),
+		(*WALRecord_AssignMission)(nil),
+		(*WALRecord_CancelMission)(nil),
+		(*WALRecord_SetTimeScale)(nil),
+		(*WALRecord_PauseSimulation)(nil),
+		(*WALRecord_ResumeSimulation)(nil),
+		(*WALRecord_CreateGeofence)(nil),
+		(*WALRecord_DeleteGeofence)(nil),
+	}
+	file_proto_machine_stream_proto_msgTypes[29].OneofWrappers = []any{
+		(*Event_GeofenceBreach)(nil),
+		(*Event_Alert)(nil),
+		(*Event_Proximity)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_machine_stream_proto_rawDesc), len(file_proto_machine_stream_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      8,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_machine_stream_proto_goTypes,
 		DependencyIndexes: file_proto_machine_stream_proto_depIdxs,
+		EnumInfos:         file_proto_machine_stream_proto_enumTypes,
 		MessageInfos:      file_proto_machine_stream_proto_msgTypes,
 	}.Build()
 	File_proto_machine_stream_proto = out.File
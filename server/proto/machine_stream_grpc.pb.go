@@ -19,18 +19,56 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MachineMap_Pause_FullMethodName         = "/proto.MachineMap/Pause"
-	MachineMap_UnPause_FullMethodName       = "/proto.MachineMap/UnPause"
-	MachineMap_MachineStream_FullMethodName = "/proto.MachineMap/MachineStream"
+	MachineMap_CreateMachine_FullMethodName    = "/proto.MachineMap/CreateMachine"
+	MachineMap_DeleteMachine_FullMethodName    = "/proto.MachineMap/DeleteMachine"
+	MachineMap_GetMachine_FullMethodName       = "/proto.MachineMap/GetMachine"
+	MachineMap_ListMachines_FullMethodName     = "/proto.MachineMap/ListMachines"
+	MachineMap_GetTrack_FullMethodName         = "/proto.MachineMap/GetTrack"
+	MachineMap_Pause_FullMethodName            = "/proto.MachineMap/Pause"
+	MachineMap_UnPause_FullMethodName          = "/proto.MachineMap/UnPause"
+	MachineMap_Refuel_FullMethodName           = "/proto.MachineMap/Refuel"
+	MachineMap_AssignMission_FullMethodName    = "/proto.MachineMap/AssignMission"
+	MachineMap_CancelMission_FullMethodName    = "/proto.MachineMap/CancelMission"
+	MachineMap_MachineStream_FullMethodName    = "/proto.MachineMap/MachineStream"
+	MachineMap_WatchFleet_FullMethodName       = "/proto.MachineMap/WatchFleet"
+	MachineMap_ListSubscribers_FullMethodName  = "/proto.MachineMap/ListSubscribers"
+	MachineMap_CreateGeofence_FullMethodName   = "/proto.MachineMap/CreateGeofence"
+	MachineMap_DeleteGeofence_FullMethodName   = "/proto.MachineMap/DeleteGeofence"
+	MachineMap_ListGeofences_FullMethodName    = "/proto.MachineMap/ListGeofences"
+	MachineMap_WatchEvents_FullMethodName      = "/proto.MachineMap/WatchEvents"
+	MachineMap_ListAlerts_FullMethodName       = "/proto.MachineMap/ListAlerts"
+	MachineMap_SetTimeScale_FullMethodName     = "/proto.MachineMap/SetTimeScale"
+	MachineMap_PauseSimulation_FullMethodName  = "/proto.MachineMap/PauseSimulation"
+	MachineMap_ResumeSimulation_FullMethodName = "/proto.MachineMap/ResumeSimulation"
+	MachineMap_StepSimulation_FullMethodName   = "/proto.MachineMap/StepSimulation"
 )
 
 // MachineMapClient is the client API for MachineMap service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MachineMapClient interface {
+	CreateMachine(ctx context.Context, in *CreateMachineRequest, opts ...grpc.CallOption) (*Machine, error)
+	DeleteMachine(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error)
+	GetMachine(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error)
+	ListMachines(ctx context.Context, in *ListMachinesRequest, opts ...grpc.CallOption) (*ListMachinesResponse, error)
+	GetTrack(ctx context.Context, in *GetTrackRequest, opts ...grpc.CallOption) (*GetTrackResponse, error)
 	Pause(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error)
 	UnPause(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error)
+	Refuel(ctx context.Context, in *RefuelRequest, opts ...grpc.CallOption) (*Machine, error)
+	AssignMission(ctx context.Context, in *AssignMissionRequest, opts ...grpc.CallOption) (*Machine, error)
+	CancelMission(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error)
 	MachineStream(ctx context.Context, in *MachineStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Machine], error)
+	WatchFleet(ctx context.Context, in *WatchFleetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FleetUpdate], error)
+	ListSubscribers(ctx context.Context, in *ListSubscribersRequest, opts ...grpc.CallOption) (*ListSubscribersResponse, error)
+	CreateGeofence(ctx context.Context, in *Geofence, opts ...grpc.CallOption) (*Geofence, error)
+	DeleteGeofence(ctx context.Context, in *Geofence, opts ...grpc.CallOption) (*Geofence, error)
+	ListGeofences(ctx context.Context, in *ListGeofencesRequest, opts ...grpc.CallOption) (*ListGeofencesResponse, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error)
+	SetTimeScale(ctx context.Context, in *SetTimeScaleRequest, opts ...grpc.CallOption) (*SimulationState, error)
+	PauseSimulation(ctx context.Context, in *PauseSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error)
+	ResumeSimulation(ctx context.Context, in *ResumeSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error)
+	StepSimulation(ctx context.Context, in *StepSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error)
 }
 
 type machineMapClient struct {
@@ -41,6 +79,56 @@ func NewMachineMapClient(cc grpc.ClientConnInterface) MachineMapClient {
 	return &machineMapClient{cc}
 }
 
+func (c *machineMapClient) CreateMachine(ctx context.Context, in *CreateMachineRequest, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_CreateMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) DeleteMachine(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_DeleteMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) GetMachine(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_GetMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) ListMachines(ctx context.Context, in *ListMachinesRequest, opts ...grpc.CallOption) (*ListMachinesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMachinesResponse)
+	err := c.cc.Invoke(ctx, MachineMap_ListMachines_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) GetTrack(ctx context.Context, in *GetTrackRequest, opts ...grpc.CallOption) (*GetTrackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTrackResponse)
+	err := c.cc.Invoke(ctx, MachineMap_GetTrack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *machineMapClient) Pause(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Machine)
@@ -61,6 +149,36 @@ func (c *machineMapClient) UnPause(ctx context.Context, in *Machine, opts ...grp
 	return out, nil
 }
 
+func (c *machineMapClient) Refuel(ctx context.Context, in *RefuelRequest, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_Refuel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) AssignMission(ctx context.Context, in *AssignMissionRequest, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_AssignMission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) CancelMission(ctx context.Context, in *Machine, opts ...grpc.CallOption) (*Machine, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineMap_CancelMission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *machineMapClient) MachineStream(ctx context.Context, in *MachineStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Machine], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &MachineMap_ServiceDesc.Streams[0], MachineMap_MachineStream_FullMethodName, cOpts...)
@@ -80,13 +198,160 @@ func (c *machineMapClient) MachineStream(ctx context.Context, in *MachineStreamR
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type MachineMap_MachineStreamClient = grpc.ServerStreamingClient[Machine]
 
+func (c *machineMapClient) WatchFleet(ctx context.Context, in *WatchFleetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FleetUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MachineMap_ServiceDesc.Streams[1], MachineMap_WatchFleet_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchFleetRequest, FleetUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MachineMap_WatchFleetClient = grpc.ServerStreamingClient[FleetUpdate]
+
+func (c *machineMapClient) ListSubscribers(ctx context.Context, in *ListSubscribersRequest, opts ...grpc.CallOption) (*ListSubscribersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSubscribersResponse)
+	err := c.cc.Invoke(ctx, MachineMap_ListSubscribers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) CreateGeofence(ctx context.Context, in *Geofence, opts ...grpc.CallOption) (*Geofence, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Geofence)
+	err := c.cc.Invoke(ctx, MachineMap_CreateGeofence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) DeleteGeofence(ctx context.Context, in *Geofence, opts ...grpc.CallOption) (*Geofence, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Geofence)
+	err := c.cc.Invoke(ctx, MachineMap_DeleteGeofence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) ListGeofences(ctx context.Context, in *ListGeofencesRequest, opts ...grpc.CallOption) (*ListGeofencesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGeofencesResponse)
+	err := c.cc.Invoke(ctx, MachineMap_ListGeofences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MachineMap_ServiceDesc.Streams[2], MachineMap_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MachineMap_WatchEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *machineMapClient) ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAlertsResponse)
+	err := c.cc.Invoke(ctx, MachineMap_ListAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) SetTimeScale(ctx context.Context, in *SetTimeScaleRequest, opts ...grpc.CallOption) (*SimulationState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimulationState)
+	err := c.cc.Invoke(ctx, MachineMap_SetTimeScale_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) PauseSimulation(ctx context.Context, in *PauseSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimulationState)
+	err := c.cc.Invoke(ctx, MachineMap_PauseSimulation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) ResumeSimulation(ctx context.Context, in *ResumeSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimulationState)
+	err := c.cc.Invoke(ctx, MachineMap_ResumeSimulation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineMapClient) StepSimulation(ctx context.Context, in *StepSimulationRequest, opts ...grpc.CallOption) (*SimulationState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimulationState)
+	err := c.cc.Invoke(ctx, MachineMap_StepSimulation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MachineMapServer is the server API for MachineMap service.
 // All implementations must embed UnimplementedMachineMapServer
 // for forward compatibility.
 type MachineMapServer interface {
+	CreateMachine(context.Context, *CreateMachineRequest) (*Machine, error)
+	DeleteMachine(context.Context, *Machine) (*Machine, error)
+	GetMachine(context.Context, *Machine) (*Machine, error)
+	ListMachines(context.Context, *ListMachinesRequest) (*ListMachinesResponse, error)
+	GetTrack(context.Context, *GetTrackRequest) (*GetTrackResponse, error)
 	Pause(context.Context, *Machine) (*Machine, error)
 	UnPause(context.Context, *Machine) (*Machine, error)
+	Refuel(context.Context, *RefuelRequest) (*Machine, error)
+	AssignMission(context.Context, *AssignMissionRequest) (*Machine, error)
+	CancelMission(context.Context, *Machine) (*Machine, error)
 	MachineStream(*MachineStreamRequest, grpc.ServerStreamingServer[Machine]) error
+	WatchFleet(*WatchFleetRequest, grpc.ServerStreamingServer[FleetUpdate]) error
+	ListSubscribers(context.Context, *ListSubscribersRequest) (*ListSubscribersResponse, error)
+	CreateGeofence(context.Context, *Geofence) (*Geofence, error)
+	DeleteGeofence(context.Context, *Geofence) (*Geofence, error)
+	ListGeofences(context.Context, *ListGeofencesRequest) (*ListGeofencesResponse, error)
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error
+	ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error)
+	SetTimeScale(context.Context, *SetTimeScaleRequest) (*SimulationState, error)
+	PauseSimulation(context.Context, *PauseSimulationRequest) (*SimulationState, error)
+	ResumeSimulation(context.Context, *ResumeSimulationRequest) (*SimulationState, error)
+	StepSimulation(context.Context, *StepSimulationRequest) (*SimulationState, error)
 	mustEmbedUnimplementedMachineMapServer()
 }
 
@@ -97,15 +362,72 @@ type MachineMapServer interface {
 // pointer dereference when methods are called.
 type UnimplementedMachineMapServer struct{}
 
+func (UnimplementedMachineMapServer) CreateMachine(context.Context, *CreateMachineRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMachine not implemented")
+}
+func (UnimplementedMachineMapServer) DeleteMachine(context.Context, *Machine) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMachine not implemented")
+}
+func (UnimplementedMachineMapServer) GetMachine(context.Context, *Machine) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachine not implemented")
+}
+func (UnimplementedMachineMapServer) ListMachines(context.Context, *ListMachinesRequest) (*ListMachinesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMachines not implemented")
+}
+func (UnimplementedMachineMapServer) GetTrack(context.Context, *GetTrackRequest) (*GetTrackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrack not implemented")
+}
 func (UnimplementedMachineMapServer) Pause(context.Context, *Machine) (*Machine, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
 }
 func (UnimplementedMachineMapServer) UnPause(context.Context, *Machine) (*Machine, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnPause not implemented")
 }
+func (UnimplementedMachineMapServer) Refuel(context.Context, *RefuelRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refuel not implemented")
+}
+func (UnimplementedMachineMapServer) AssignMission(context.Context, *AssignMissionRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignMission not implemented")
+}
+func (UnimplementedMachineMapServer) CancelMission(context.Context, *Machine) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelMission not implemented")
+}
 func (UnimplementedMachineMapServer) MachineStream(*MachineStreamRequest, grpc.ServerStreamingServer[Machine]) error {
 	return status.Errorf(codes.Unimplemented, "method MachineStream not implemented")
 }
+func (UnimplementedMachineMapServer) WatchFleet(*WatchFleetRequest, grpc.ServerStreamingServer[FleetUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchFleet not implemented")
+}
+func (UnimplementedMachineMapServer) ListSubscribers(context.Context, *ListSubscribersRequest) (*ListSubscribersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscribers not implemented")
+}
+func (UnimplementedMachineMapServer) CreateGeofence(context.Context, *Geofence) (*Geofence, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGeofence not implemented")
+}
+func (UnimplementedMachineMapServer) DeleteGeofence(context.Context, *Geofence) (*Geofence, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteGeofence not implemented")
+}
+func (UnimplementedMachineMapServer) ListGeofences(context.Context, *ListGeofencesRequest) (*ListGeofencesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGeofences not implemented")
+}
+func (UnimplementedMachineMapServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedMachineMapServer) ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAlerts not implemented")
+}
+func (UnimplementedMachineMapServer) SetTimeScale(context.Context, *SetTimeScaleRequest) (*SimulationState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTimeScale not implemented")
+}
+func (UnimplementedMachineMapServer) PauseSimulation(context.Context, *PauseSimulationRequest) (*SimulationState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseSimulation not implemented")
+}
+func (UnimplementedMachineMapServer) ResumeSimulation(context.Context, *ResumeSimulationRequest) (*SimulationState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeSimulation not implemented")
+}
+func (UnimplementedMachineMapServer) StepSimulation(context.Context, *StepSimulationRequest) (*SimulationState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StepSimulation not implemented")
+}
 func (UnimplementedMachineMapServer) mustEmbedUnimplementedMachineMapServer() {}
 func (UnimplementedMachineMapServer) testEmbeddedByValue()                    {}
 
@@ -127,6 +449,96 @@ func RegisterMachineMapServer(s grpc.ServiceRegistrar, srv MachineMapServer) {
 	s.RegisterService(&MachineMap_ServiceDesc, srv)
 }
 
+func _MachineMap_CreateMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).CreateMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_CreateMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).CreateMachine(ctx, req.(*CreateMachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_DeleteMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Machine)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).DeleteMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_DeleteMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).DeleteMachine(ctx, req.(*Machine))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_GetMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Machine)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).GetMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_GetMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).GetMachine(ctx, req.(*Machine))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_ListMachines_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMachinesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).ListMachines(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_ListMachines_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).ListMachines(ctx, req.(*ListMachinesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_GetTrack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTrackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).GetTrack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_GetTrack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).GetTrack(ctx, req.(*GetTrackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MachineMap_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Machine)
 	if err := dec(in); err != nil {
@@ -163,6 +575,60 @@ func _MachineMap_UnPause_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MachineMap_Refuel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefuelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).Refuel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_Refuel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).Refuel(ctx, req.(*RefuelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_AssignMission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignMissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).AssignMission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_AssignMission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).AssignMission(ctx, req.(*AssignMissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_CancelMission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Machine)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).CancelMission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_CancelMission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).CancelMission(ctx, req.(*Machine))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MachineMap_MachineStream_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(MachineStreamRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -174,6 +640,190 @@ func _MachineMap_MachineStream_Handler(srv interface{}, stream grpc.ServerStream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type MachineMap_MachineStreamServer = grpc.ServerStreamingServer[Machine]
 
+func _MachineMap_WatchFleet_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchFleetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MachineMapServer).WatchFleet(m, &grpc.GenericServerStream[WatchFleetRequest, FleetUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MachineMap_WatchFleetServer = grpc.ServerStreamingServer[FleetUpdate]
+
+func _MachineMap_ListSubscribers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubscribersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).ListSubscribers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_ListSubscribers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).ListSubscribers(ctx, req.(*ListSubscribersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_CreateGeofence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Geofence)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).CreateGeofence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_CreateGeofence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).CreateGeofence(ctx, req.(*Geofence))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_DeleteGeofence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Geofence)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).DeleteGeofence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_DeleteGeofence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).DeleteGeofence(ctx, req.(*Geofence))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_ListGeofences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGeofencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).ListGeofences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_ListGeofences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).ListGeofences(ctx, req.(*ListGeofencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MachineMapServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MachineMap_WatchEventsServer = grpc.ServerStreamingServer[Event]
+
+func _MachineMap_ListAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).ListAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_ListAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).ListAlerts(ctx, req.(*ListAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_SetTimeScale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTimeScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).SetTimeScale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_SetTimeScale_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).SetTimeScale(ctx, req.(*SetTimeScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_PauseSimulation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseSimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).PauseSimulation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_PauseSimulation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).PauseSimulation(ctx, req.(*PauseSimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_ResumeSimulation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeSimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).ResumeSimulation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_ResumeSimulation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).ResumeSimulation(ctx, req.(*ResumeSimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineMap_StepSimulation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepSimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineMapServer).StepSimulation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineMap_StepSimulation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineMapServer).StepSimulation(ctx, req.(*StepSimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MachineMap_ServiceDesc is the grpc.ServiceDesc for MachineMap service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -181,6 +831,26 @@ var MachineMap_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.MachineMap",
 	HandlerType: (*MachineMapServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateMachine",
+			Handler:    _MachineMap_CreateMachine_Handler,
+		},
+		{
+			MethodName: "DeleteMachine",
+			Handler:    _MachineMap_DeleteMachine_Handler,
+		},
+		{
+			MethodName: "GetMachine",
+			Handler:    _MachineMap_GetMachine_Handler,
+		},
+		{
+			MethodName: "ListMachines",
+			Handler:    _MachineMap_ListMachines_Handler,
+		},
+		{
+			MethodName: "GetTrack",
+			Handler:    _MachineMap_GetTrack_Handler,
+		},
 		{
 			MethodName: "Pause",
 			Handler:    _MachineMap_Pause_Handler,
@@ -189,6 +859,54 @@ var MachineMap_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnPause",
 			Handler:    _MachineMap_UnPause_Handler,
 		},
+		{
+			MethodName: "Refuel",
+			Handler:    _MachineMap_Refuel_Handler,
+		},
+		{
+			MethodName: "AssignMission",
+			Handler:    _MachineMap_AssignMission_Handler,
+		},
+		{
+			MethodName: "CancelMission",
+			Handler:    _MachineMap_CancelMission_Handler,
+		},
+		{
+			MethodName: "ListSubscribers",
+			Handler:    _MachineMap_ListSubscribers_Handler,
+		},
+		{
+			MethodName: "CreateGeofence",
+			Handler:    _MachineMap_CreateGeofence_Handler,
+		},
+		{
+			MethodName: "DeleteGeofence",
+			Handler:    _MachineMap_DeleteGeofence_Handler,
+		},
+		{
+			MethodName: "ListGeofences",
+			Handler:    _MachineMap_ListGeofences_Handler,
+		},
+		{
+			MethodName: "ListAlerts",
+			Handler:    _MachineMap_ListAlerts_Handler,
+		},
+		{
+			MethodName: "SetTimeScale",
+			Handler:    _MachineMap_SetTimeScale_Handler,
+		},
+		{
+			MethodName: "PauseSimulation",
+			Handler:    _MachineMap_PauseSimulation_Handler,
+		},
+		{
+			MethodName: "ResumeSimulation",
+			Handler:    _MachineMap_ResumeSimulation_Handler,
+		},
+		{
+			MethodName: "StepSimulation",
+			Handler:    _MachineMap_StepSimulation_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -196,6 +914,16 @@ var MachineMap_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _MachineMap_MachineStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchFleet",
+			Handler:       _MachineMap_WatchFleet_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _MachineMap_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/machine_stream.proto",
 }
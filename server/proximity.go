@@ -0,0 +1,196 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"strings"
+	"time"
+)
+
+const (
+	metersPerDegreeLat = math.Pi * earthRadiusMeters / 180
+	proximityClearance = 1.2 // pairs clear once this many separations apart, so a pair on the edge does not flap
+)
+
+// Proximity finds pairs of machines closer than the separation distance after every tick, using a
+// grid of cells at least as wide as the distance at which a pair clears. Only the tick goroutine uses it
+type Proximity struct {
+	separation float64 // meters
+	policy     pb.AvoidancePolicy
+	pairs      map[machinePair]bool // pairs currently too close
+}
+
+type gridCell struct{ lat, lon int64 }
+
+// machinePair is ordered, a < b
+type machinePair struct{ a, b uint32 }
+
+// WithProximity reports machines that come within separation meters of each other, applying policy
+// to one machine of each pair
+func WithProximity(separation float64, policy pb.AvoidancePolicy) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.proximity = &Proximity{separation: separation, policy: policy, pairs: make(map[machinePair]bool)}
+	}
+}
+
+func parseAvoidancePolicy(name string) (pb.AvoidancePolicy, error) {
+	value, ok := pb.AvoidancePolicy_value[strings.ToUpper(strings.ReplaceAll(name, "-", "_"))]
+	if !ok {
+		return 0, fmt.Errorf("unknown avoidance policy %q", name)
+	}
+	return pb.AvoidancePolicy(value), nil
+}
+
+// closePairs returns every pair of machines within the separation distance, or within the
+// clearance distance for pairs that were already close, with the distance between them
+func (p *Proximity) closePairs(machines map[uint32]*pb.Machine) map[machinePair]float64 {
+	reach := p.separation * proximityClearance
+	maxLat := 0.0
+	for _, machine := range machines {
+		maxLat = max(maxLat, math.Abs(machine.Location.Lat))
+	}
+	// Degrees of longitude shrink towards the poles, so size cells for the machine furthest from the equator
+	latSize := reach / metersPerDegreeLat
+	lonSize := latSize / max(math.Cos(toRadians(maxLat)), 0.01)
+
+	grid := make(map[gridCell][]*pb.Machine)
+	for _, machine := range machines {
+		cell := gridCell{int64(math.Floor(machine.Location.Lat / latSize)), int64(math.Floor(machine.Location.Lon / lonSize))}
+		grid[cell] = append(grid[cell], machine)
+	}
+
+	near := make(map[machinePair]float64)
+	for cell, members := range grid {
+		for _, machine := range members {
+			for dLat := int64(-1); dLat <= 1; dLat++ {
+				for dLon := int64(-1); dLon <= 1; dLon++ {
+					for _, other := range grid[gridCell{cell.lat + dLat, cell.lon + dLon}] {
+						if other.Id <= machine.Id {
+							continue
+						}
+						pair := machinePair{machine.Id, other.Id}
+						distance := haversineMeters(machine.Location, other.Location)
+						if distance < p.separation || (p.pairs[pair] && distance < reach) {
+							near[pair] = distance
+						}
+					}
+				}
+			}
+		}
+	}
+	return near
+}
+
+// detectProximity reports pairs of machines that got too close or clear again since the last tick,
+// applying the avoidance policy to new pairs. Machines moved or paused are updated in the snapshot
+func (mm *MachineManager) detectProximity(snapshot *FleetSnapshot) {
+	p := mm.proximity
+	if p == nil || p.separation <= 0 {
+		return
+	}
+	now := snapshot.time
+	near := p.closePairs(snapshot.machines)
+
+	// Handle pairs in id order, so seeded fleets avoid each other the same way every run
+	var started, cleared []machinePair
+	for pair := range near {
+		if !p.pairs[pair] {
+			started = append(started, pair)
+		}
+	}
+	for pair := range p.pairs {
+		if _, ok := near[pair]; !ok {
+			cleared = append(cleared, pair)
+		}
+	}
+	comparePairs := func(x, y machinePair) int { return cmp.Or(cmp.Compare(x.a, y.a), cmp.Compare(x.b, y.b)) }
+	slices.SortFunc(started, comparePairs)
+	slices.SortFunc(cleared, comparePairs)
+
+	for _, pair := range cleared {
+		delete(p.pairs, pair)
+		a, b := snapshot.machines[pair.a], snapshot.machines[pair.b]
+		var distance float64
+		if a != nil && b != nil {
+			distance = haversineMeters(a.Location, b.Location)
+		}
+		mm.publishProximity(pair.a, pair.b, a, b, distance, pb.AvoidancePolicy_NO_AVOIDANCE, true, now)
+		mm.publishProximity(pair.b, pair.a, b, a, distance, pb.AvoidancePolicy_NO_AVOIDANCE, true, now)
+	}
+
+	for _, pair := range started {
+		p.pairs[pair] = true
+		a, b := snapshot.machines[pair.a], snapshot.machines[pair.b]
+		distance := near[pair]
+
+		// The newer machine gives way, unless only the older one is moving
+		actionA, actionB := pb.AvoidancePolicy_NO_AVOIDANCE, pb.AvoidancePolicy_NO_AVOIDANCE
+		if yielder, other := yieldingMachine(a, b); yielder != nil && p.policy != pb.AvoidancePolicy_NO_AVOIDANCE {
+			if sample := mm.avoid(yielder, other, distance, now); sample != nil {
+				snapshot.machines[sample.Id] = sample
+			}
+			if yielder == a {
+				actionA = p.policy
+			} else {
+				actionB = p.policy
+			}
+		}
+		mm.publishProximity(pair.a, pair.b, a, b, distance, actionA, false, now)
+		mm.publishProximity(pair.b, pair.a, b, a, distance, actionB, false, now)
+	}
+}
+
+// yieldingMachine picks which of a pair gives way: the newer one if it is moving, else the older
+// one if it is moving. Paused machines never give way
+func yieldingMachine(a, b *pb.Machine) (*pb.Machine, *pb.Machine) {
+	moving := func(m *pb.Machine) bool {
+		return m.Status == pb.MachineStatus_MOVING || m.Status == pb.MachineStatus_RETURNING
+	}
+	switch {
+	case moving(b):
+		return b, a
+	case moving(a):
+		return a, b
+	}
+	return nil, nil
+}
+
+// avoid applies the avoidance policy to a machine too close to another one, returning its new state
+func (mm *MachineManager) avoid(yielder, other *pb.Machine, distance float64, now time.Time) *pb.Machine {
+	machine, exists := mm.getMachine(yielder.Id)
+	if !exists {
+		return nil
+	}
+
+	machine.mutex.Lock()
+	switch mm.proximity.policy {
+	case pb.AvoidancePolicy_NUDGE_APART:
+		bearing := 0.0 // due north when both are on the same spot
+		if distance > 0 {
+			bearing = bearingDegrees(other.Location, machine.Location)
+		}
+		machine.Location.Lat, machine.Location.Lon = destinationPoint(machine.Location, bearing, mm.proximity.separation*proximityClearance-distance)
+		machine.track.record(now, machine)
+	case pb.AvoidancePolicy_PAUSE_ONE:
+		if !machine.isPaused() {
+			machine.Status = pb.MachineStatus_IDLE
+		}
+	}
+	machine.mutex.Unlock()
+
+	return mm.notifyChange(machine)
+}
+
+func (mm *MachineManager) publishProximity(machineID, otherID uint32, machine, other *pb.Machine, distance float64, action pb.AvoidancePolicy, cleared bool, now time.Time) {
+	proximity := &pb.Proximity{OtherMachineId: otherID, Distance: distance, Action: action, Cleared: cleared}
+	if machine != nil {
+		proximity.Location = machine.Location
+	}
+	if other != nil {
+		proximity.OtherLocation = other.Location
+	}
+	mm.publishEvent(machineID, now, &pb.Event{Detail: &pb.Event_Proximity{Proximity: proximity}})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+)
+
+func TestClosePairsMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	machines := make(map[uint32]*pb.Machine)
+	for id := uint32(1); id <= 500; id++ {
+		machines[id] = &pb.Machine{Id: id, Location: &pb.GPS{Lat: 47.695 + 0.003*rng.Float64(), Lon: -122.145 + 0.003*rng.Float64()}}
+	}
+	p := &Proximity{separation: 15, pairs: map[machinePair]bool{{1, 2}: true}}
+	machines[2].Location = &pb.GPS{Lat: machines[1].Location.Lat + 16/metersPerDegreeLat, Lon: machines[1].Location.Lon}
+
+	got := p.closePairs(machines)
+	want := 0
+	for a := uint32(1); a <= 500; a++ {
+		for b := a + 1; b <= 500; b++ {
+			distance := haversineMeters(machines[a].Location, machines[b].Location)
+			pair := machinePair{a, b}
+			if distance < p.separation || (p.pairs[pair] && distance < p.separation*proximityClearance) {
+				want++
+				if math.Abs(got[pair]-distance) > 1e-9 {
+					t.Errorf("pair %v: got %v, want %.2f m", pair, got[pair], distance)
+				}
+			}
+		}
+	}
+	if len(got) != want {
+		t.Errorf("got %d close pairs, want %d", len(got), want)
+	}
+	if _, ok := got[machinePair{1, 2}]; !ok {
+		t.Errorf("pair 1, 2 is 16 m apart and already close, want it kept until the clearance distance")
+	}
+}
+
+// setupProximityServer creates two machines about 13 m apart in a paused simulation, the newer one moving
+func setupProximityServer(t *testing.T, policy pb.AvoidancePolicy) (*MachineManager, *Subscription[*pb.Event]) {
+	t.Helper()
+	mm := NewMachineManager(WithProximity(20, policy))
+	t.Cleanup(mm.Close)
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+	mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+	mm.CreateMachine(ctx, &pb.CreateMachineRequest{MotionModel: pb.MotionModelType_DEAD_RECKONING, Speed: 1})
+	mm.UnPause(ctx, &pb.Machine{Id: 2})
+	sub, _ := mm.events.Subscribe(SubscribeOptions{})
+	return mm, sub
+}
+
+func proximities(sub *Subscription[*pb.Event]) map[uint32]*pb.Proximity {
+	proximities := make(map[uint32]*pb.Proximity)
+	for _, event := range sub.Drain() {
+		proximities[event.MachineId] = event.GetProximity()
+	}
+	return proximities
+}
+
+func TestProximityNudgesNewerMachineApart(t *testing.T) {
+	mm, sub := setupProximityServer(t, pb.AvoidancePolicy_NUDGE_APART)
+	ctx := context.Background()
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	got := proximities(sub)
+	if len(got) != 2 || got[1].OtherMachineId != 2 || got[2].OtherMachineId != 1 || got[1].Distance >= 20 {
+		t.Fatalf("got %v, want the encounter reported to both machines", got)
+	}
+	if got[1].Action != pb.AvoidancePolicy_NO_AVOIDANCE || got[2].Action != pb.AvoidancePolicy_NUDGE_APART {
+		t.Errorf("got actions %v and %v, want machine 2 nudged", got[1].Action, got[2].Action)
+	}
+
+	first, _ := mm.GetMachine(ctx, &pb.Machine{Id: 1})
+	second, _ := mm.GetMachine(ctx, &pb.Machine{Id: 2})
+	if distance := haversineMeters(first.Location, second.Location); math.Abs(distance-24) > 0.01 {
+		t.Errorf("got machines %.2f m apart, want nudged to 24 m", distance)
+	}
+
+	mm.DeleteMachine(ctx, &pb.Machine{Id: 2})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := proximities(sub); len(got) != 2 || !got[1].Cleared || !got[2].Cleared {
+		t.Errorf("got %v after deleting machine 2, want the encounter cleared", got)
+	}
+}
+
+func TestProximityPausesOneMachine(t *testing.T) {
+	mm, sub := setupProximityServer(t, pb.AvoidancePolicy_PAUSE_ONE)
+	ctx := context.Background()
+
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := proximities(sub); got[2].GetAction() != pb.AvoidancePolicy_PAUSE_ONE {
+		t.Fatalf("got %v, want machine 2 paused", got)
+	}
+	if second, _ := mm.GetMachine(ctx, &pb.Machine{Id: 2}); second.Status != pb.MachineStatus_IDLE {
+		t.Errorf("got status %v, want IDLE", second.Status)
+	}
+
+	// Still close, so nothing more is reported
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 1})
+	if got := proximities(sub); len(got) != 0 {
+		t.Errorf("got %v, want the encounter reported once", got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// gRPC method to read the current state of a single machine
+func (mm *MachineManager) GetMachine(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return mm.machineToProto(machine), nil
+}
+
+// gRPC method to list machines ordered by id. The page token is the id of the
+// last machine on the previous page
+func (mm *MachineManager) ListMachines(ctx context.Context, req *pb.ListMachinesRequest) (*pb.ListMachinesResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var after uint32
+	if req.PageToken != "" {
+		parsed, err := strconv.ParseUint(req.PageToken, 10, 32)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %q", req.PageToken)
+		}
+		after = uint32(parsed)
+	}
+	// A NaN filter would silently match nothing
+	if (req.MinFuelLevel != nil && math.IsNaN(float64(*req.MinFuelLevel))) || (req.MaxFuelLevel != nil && math.IsNaN(float64(*req.MaxFuelLevel))) {
+		return nil, status.Error(codes.InvalidArgument, "fuel level filter must be a number")
+	}
+	if req.MinFuelLevel != nil && req.MaxFuelLevel != nil && *req.MinFuelLevel > *req.MaxFuelLevel {
+		return nil, status.Error(codes.InvalidArgument, "min_fuel_level is greater than max_fuel_level")
+	}
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	ids := make([]uint32, 0, len(mm.machines))
+	for id := range mm.machines {
+		if id > after {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	resp := &pb.ListMachinesResponse{}
+	for _, id := range ids {
+		machine := mm.machineToProto(mm.machines[id])
+		if !matchesFilter(machine, req) {
+			continue
+		}
+		if len(resp.Machines) == pageSize {
+			resp.NextPageToken = strconv.FormatUint(uint64(resp.Machines[pageSize-1].Id), 10)
+			break
+		}
+		resp.Machines = append(resp.Machines, machine)
+	}
+
+	return resp, nil
+}
+
+// matchesFilter reports whether a machine satisfies every filter set on the request
+func matchesFilter(machine *pb.Machine, req *pb.ListMachinesRequest) bool {
+	if req.IsPaused != nil && machine.IsPaused != *req.IsPaused {
+		return false
+	}
+	if req.MinFuelLevel != nil && machine.FuelLevel < *req.MinFuelLevel {
+		return false
+	}
+	if req.MaxFuelLevel != nil && machine.FuelLevel > *req.MaxFuelLevel {
+		return false
+	}
+	if bounds := req.Bounds; bounds != nil {
+		location := machine.Location
+		if location.Lat < bounds.MinLat || location.Lat > bounds.MaxLat ||
+			location.Lon < bounds.MinLon || location.Lon > bounds.MaxLon {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListMachinesPagination(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for i := 0; i < 5; i++ {
+		mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{})
+	}
+	mm.UnPause(context.Background(), &pb.Machine{Id: 2})
+
+	paused := true
+	var ids []uint32
+	req := &pb.ListMachinesRequest{PageSize: 2, IsPaused: &paused}
+	for {
+		resp, err := mm.ListMachines(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ListMachines failed: %v", err)
+		}
+		for _, machine := range resp.Machines {
+			ids = append(ids, machine.Id)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	want := []uint32{1, 3, 4, 5}
+	if len(ids) != len(want) {
+		t.Fatalf("got ids %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("got ids %v, want %v", ids, want)
+		}
+	}
+}
+
+func TestListMachinesInvalidPageToken(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if _, err := mm.ListMachines(context.Background(), &pb.ListMachinesRequest{PageToken: "bogus"}); err == nil {
+		t.Errorf("expected error for invalid page token")
+	}
+}
+
+func TestListMachinesRejectsNaNFuelFilter(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	nan := float32(math.NaN())
+	for _, req := range []*pb.ListMachinesRequest{{MinFuelLevel: &nan}, {MaxFuelLevel: &nan}} {
+		if _, err := mm.ListMachines(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got %v, want InvalidArgument", req, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// Recorder writes every machine update the manager publishes to a file of length-delimited
+// FleetEvent messages, starting with a SNAPSHOT event for each machine. Recordings are
+// played back with the -replay server mode
+type Recorder struct {
+	file   *os.File
+	writer *bufio.Writer
+	broker *Broker[*pb.FleetEvent]
+	sub    *Subscription[*pb.FleetEvent]
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// StartRecording creates the recording file at path and records until the Recorder is closed
+func (mm *MachineManager) StartRecording(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queue generously, a recording should only drop updates if the disk cannot keep up
+	sub, err := mm.broker.Subscribe(SubscribeOptions{
+		Method: "Recorder",
+		Policy: pb.SlowConsumerPolicy_DROP_OLDEST,
+		MaxLag: maxMaxLag,
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	r := &Recorder{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		broker: mm.broker,
+		sub:    sub,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	mm.mu.RLock()
+	machines := make([]*Machine, 0, len(mm.machines))
+	for _, machine := range mm.machines {
+		machines = append(machines, machine)
+	}
+	mm.mu.RUnlock()
+
+	for _, machine := range machines {
+		if err := r.write(&pb.FleetEvent{Type: pb.FleetEvent_SNAPSHOT, Machine: mm.machineToProto(machine)}); err != nil {
+			r.broker.Unsubscribe(sub)
+			file.Close()
+			return nil, err
+		}
+	}
+
+	go r.run()
+	return r, nil
+}
+
+func (r *Recorder) write(event *pb.FleetEvent) error {
+	_, err := protodelim.MarshalTo(r.writer, event)
+	return err
+}
+
+// run writes events as they are published, flushing after every batch
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			r.writeQueued()
+			return
+		case <-r.sub.Ready():
+			if !r.writeQueued() {
+				return
+			}
+		}
+	}
+}
+
+func (r *Recorder) writeQueued() bool {
+	for _, event := range r.sub.Drain() {
+		if err := r.write(event); err != nil {
+			log.Printf("Recording stopped: %v", err)
+			return false
+		}
+	}
+	if err := r.writer.Flush(); err != nil {
+		log.Printf("Recording stopped: %v", err)
+		return false
+	}
+	return true
+}
+
+// Close writes any queued updates and closes the recording file
+func (r *Recorder) Close() error {
+	close(r.stop)
+	<-r.done
+	r.broker.Unsubscribe(r.sub)
+
+	if dropped := r.sub.dropped.Load(); dropped > 0 {
+		log.Printf("Recording dropped %d updates", dropped)
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+func readRecording(t *testing.T, path string) []*pb.FleetEvent {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading recording: %v", err)
+	}
+
+	var events []*pb.FleetEvent
+	reader := bytes.NewReader(data)
+	for reader.Len() > 0 {
+		event := &pb.FleetEvent{}
+		if err := protodelim.UnmarshalFrom(reader, event); err != nil {
+			t.Fatalf("reading recording: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// recordSession records a short session: one machine existing beforehand, one added, both
+// moving for three ticks, then the added one deleted
+func recordSession(t *testing.T, path string) *MachineManager {
+	t.Helper()
+	mm := NewMachineManager(WithSeed(3))
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+	existing, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+
+	recorder, err := mm.StartRecording(path)
+	if err != nil {
+		t.Fatalf("StartRecording failed: %v", err)
+	}
+	added, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{})
+	mm.UnPause(ctx, &pb.Machine{Id: existing.Id})
+	mm.UnPause(ctx, &pb.Machine{Id: added.Id})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 3})
+	mm.DeleteMachine(ctx, &pb.Machine{Id: added.Id})
+
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("closing recorder: %v", err)
+	}
+	return mm
+}
+
+func TestRecordingCapturesEveryUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.pb")
+	mm := recordSession(t, path)
+	defer mm.Close()
+
+	var types []pb.FleetEvent_Type
+	for _, event := range readRecording(t, path) {
+		types = append(types, event.Type)
+	}
+	want := []pb.FleetEvent_Type{
+		pb.FleetEvent_SNAPSHOT, pb.FleetEvent_ADDED, pb.FleetEvent_UPDATED, pb.FleetEvent_UPDATED,
+		pb.FleetEvent_UPDATED, pb.FleetEvent_UPDATED, // tick 1
+		pb.FleetEvent_UPDATED, pb.FleetEvent_UPDATED, // tick 2
+		pb.FleetEvent_UPDATED, pb.FleetEvent_UPDATED, // tick 3
+		pb.FleetEvent_REMOVED,
+	}
+	if len(types) != len(want) {
+		t.Fatalf("got events %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("got events %v, want %v", types, want)
+		}
+	}
+}
+
+func TestReplayServesRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.pb")
+	mm := recordSession(t, path)
+	defer mm.Close()
+
+	replay, err := OpenReplay(path, 1000)
+	if err != nil {
+		t.Fatalf("OpenReplay failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeFleetStream{ctx: ctx, updates: make(chan *pb.FleetUpdate, 32)}
+	go replay.WatchFleet(&pb.WatchFleetRequest{}, stream)
+	if snapshot := nextFleetUpdate(t, stream); len(snapshot.Events) != 0 {
+		t.Fatalf("got %v before the replay started, want an empty fleet", snapshot)
+	}
+
+	if err := replay.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	defer replay.Close()
+
+	// The replayed fleet ends as the recorded one did, and watchers saw every recorded update
+	machine, _ := mm.getMachine(1)
+	want := mm.machineToProto(machine)
+	got, err := replay.GetMachine(context.Background(), &pb.Machine{Id: 1})
+	if err != nil || !proto.Equal(got, want) {
+		t.Errorf("got %v, %v, want %v", got, err, want)
+	}
+	if _, err := replay.GetMachine(context.Background(), &pb.Machine{Id: 2}); err == nil {
+		t.Errorf("deleted machine 2 is still served")
+	}
+
+	recorded := readRecording(t, path)
+	var watched []*pb.FleetEvent
+	for len(watched) < len(recorded) {
+		watched = append(watched, nextFleetUpdate(t, stream).Events...)
+	}
+	for i, event := range watched {
+		if !proto.Equal(event.Machine, recorded[i].Machine) {
+			t.Errorf("event %d: got %v, want %v", i, event.Machine, recorded[i].Machine)
+		}
+	}
+	if _, err := replay.Pause(context.Background(), &pb.Machine{Id: 1}); err == nil {
+		t.Errorf("Pause succeeded during replay, want commands rejected")
+	}
+}
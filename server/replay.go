@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// ReplayServer serves a recording made with StartRecording instead of simulating. Machines appear,
+// move and disappear as they did when recorded, paced by their timestamps. Commands are not
+// supported, only GetMachine, MachineStream and WatchFleet
+type ReplayServer struct {
+	pb.UnimplementedMachineMapServer
+	mu       sync.RWMutex
+	machines map[uint32]*pb.Machine // latest recorded state of every machine
+	broker   *Broker[*pb.FleetEvent]
+	reader   *bufio.Reader
+	file     *os.File
+	speed    float64 // recorded seconds played per wall clock second
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// OpenReplay prepares to play the recording at path, speed times faster than it was recorded
+func OpenReplay(path string, speed float64) (*ReplayServer, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be positive")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayServer{
+		machines: make(map[uint32]*pb.Machine),
+		broker:   NewFleetBroker(),
+		reader:   bufio.NewReader(file),
+		file:     file,
+		speed:    speed,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Run plays the recording until it ends or Close is called. Machines keep their last recorded
+// state once the recording ends
+func (r *ReplayServer) Run() error {
+	defer close(r.done)
+
+	var recordedStart, wallStart time.Time
+	for {
+		event := &pb.FleetEvent{}
+		err := protodelim.UnmarshalFrom(r.reader, event)
+		if err == io.EOF {
+			log.Printf("Replay finished, serving the last recorded state")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading recording: %w", err)
+		}
+		if event.Machine == nil {
+			continue
+		}
+
+		recorded := event.Machine.Timestamp.AsTime()
+		if wallStart.IsZero() {
+			recordedStart, wallStart = recorded, time.Now()
+		}
+		due := wallStart.Add(time.Duration(float64(recorded.Sub(recordedStart)) / r.speed))
+		if wait := time.Until(due); wait > 0 {
+			select {
+			case <-r.stop:
+				return nil
+			case <-time.After(wait):
+			}
+		}
+
+		select {
+		case <-r.stop:
+			return nil
+		default:
+		}
+		r.apply(event)
+	}
+}
+
+// apply updates the replayed fleet and publishes the event. Machines already present when
+// recording started are announced as added
+func (r *ReplayServer) apply(event *pb.FleetEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.Type == pb.FleetEvent_SNAPSHOT {
+		event.Type = pb.FleetEvent_ADDED
+	}
+	if event.Type == pb.FleetEvent_REMOVED {
+		delete(r.machines, event.Machine.Id)
+	} else {
+		r.machines[event.Machine.Id] = event.Machine
+	}
+	r.broker.Publish(event)
+}
+
+// Close stops the replay and closes the recording
+func (r *ReplayServer) Close() {
+	close(r.stop)
+	<-r.done
+	r.file.Close()
+}
+
+func (r *ReplayServer) lookupMachine(id uint32) (*pb.Machine, error) {
+	if err := validateMachineID(id); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	machine, exists := r.machines[id]
+	if !exists {
+		return nil, errMachineNotFound(id)
+	}
+	return machine, nil
+}
+
+// gRPC method to fetch the replayed state of one machine
+func (r *ReplayServer) GetMachine(ctx context.Context, req *pb.Machine) (*pb.Machine, error) {
+	return r.lookupMachine(req.Id)
+}
+
+// gRPC method to stream a replayed machine
+func (r *ReplayServer) MachineStream(req *pb.MachineStreamRequest, stream pb.MachineMap_MachineStreamServer) error {
+	if _, err := r.lookupMachine(req.Id); err != nil {
+		return err
+	}
+
+	sub, err := r.broker.Subscribe(SubscribeOptions{
+		Method:  "MachineStream",
+		Machine: req.Id,
+		Policy:  req.SlowConsumerPolicy,
+		MaxLag:  req.MaxLag,
+	})
+	if err != nil {
+		return err
+	}
+	defer r.broker.Unsubscribe(sub)
+
+	// Take the initial state after subscribing so no update in between is missed
+	initial, err := r.lookupMachine(req.Id)
+	if err != nil {
+		return nil // removed in between
+	}
+	if err := stream.Send(initial); err != nil {
+		return err
+	}
+	return forwardMachine(stream, sub)
+}
+
+// gRPC method to watch the whole replayed fleet
+func (r *ReplayServer) WatchFleet(req *pb.WatchFleetRequest, stream pb.MachineMap_WatchFleetServer) error {
+	sub, err := r.broker.Subscribe(SubscribeOptions{
+		Method: "WatchFleet",
+		Policy: req.SlowConsumerPolicy,
+		MaxLag: req.MaxLag,
+	})
+	if err != nil {
+		return err
+	}
+	defer r.broker.Unsubscribe(sub)
+
+	r.mu.RLock()
+	snapshot := &pb.FleetUpdate{Events: make([]*pb.FleetEvent, 0, len(r.machines))}
+	for _, machine := range r.machines {
+		snapshot.Events = append(snapshot.Events, &pb.FleetEvent{Type: pb.FleetEvent_SNAPSHOT, Machine: machine})
+	}
+	r.mu.RUnlock()
+
+	if err := stream.Send(snapshot); err != nil {
+		return err
+	}
+	return forwardFleet(stream, sub)
+}
+
+// gRPC method to inspect the lag and drop counters of every open stream
+func (r *ReplayServer) ListSubscribers(ctx context.Context, req *pb.ListSubscribersRequest) (*pb.ListSubscribersResponse, error) {
+	return &pb.ListSubscribersResponse{Subscribers: r.broker.Stats()}, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"runtime"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"time"
+)
+
+// minShardSize keeps small fleets on a single goroutine, where spreading the work costs more than it saves
+const minShardSize = 1024
+
+// FleetSnapshot is the state of the machines a tick changed, gathered from every shard so
+// proximity can be checked across the whole fleet at once
+type FleetSnapshot struct {
+	tick     uint64
+	time     time.Time
+	machines map[uint32]*pb.Machine
+}
+
+func newFleetSnapshot(tick uint64, at time.Time, size int) *FleetSnapshot {
+	return &FleetSnapshot{
+		tick:     tick,
+		time:     at,
+		machines: make(map[uint32]*pb.Machine, size),
+	}
+}
+
+// tick advances every machine by one step of simulated time, spread across shards of
+// the fleet. Changed machines are pushed to subscribers as they are advanced, then
+// their snapshot is checked for machines too close to each other
+func (mm *MachineManager) tick(t clockTick) {
+	mm.mu.RLock()
+	machines := make([]*Machine, 0, len(mm.machines))
+	for _, machine := range mm.machines {
+		machines = append(machines, machine)
+	}
+	mm.mu.RUnlock()
+
+	shards := max(1, min(runtime.GOMAXPROCS(0), len(machines)/minShardSize))
+	shardSize := (len(machines) + shards - 1) / shards
+	results := make([][]*pb.Machine, shards)
+
+	var wg sync.WaitGroup
+	for shard := range shards {
+		start := min(shard*shardSize, len(machines))
+		end := min(start+shardSize, len(machines))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			samples := make([]*pb.Machine, 0, end-start)
+			for _, machine := range machines[start:end] {
+				mm.advanceMachine(machine, t.elapsed, t.now)
+				if sample := mm.notifyChange(machine); sample != nil {
+					samples = append(samples, sample)
+				}
+			}
+			results[shard] = samples
+		}()
+	}
+	wg.Wait()
+
+	snapshot := newFleetSnapshot(t.number, t.now, len(machines))
+	for _, samples := range results {
+		for _, sample := range samples {
+			snapshot.machines[sample.Id] = sample
+		}
+	}
+	mm.detectProximity(snapshot)
+}
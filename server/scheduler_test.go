@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+)
+
+// newBenchmarkFleet creates a paused-clock manager with n moving machines
+func newBenchmarkFleet(tb testing.TB, n int) *MachineManager {
+	mm := NewMachineManager(WithSeed(1))
+	mm.clock.update(func() { mm.clock.paused = true })
+
+	for i := 0; i < n; i++ {
+		machine, err := mm.createMachine(&pb.CreateMachineRequest{MotionModel: pb.MotionModelType(i % len(pb.MotionModelType_name))})
+		if err != nil {
+			tb.Fatalf("createMachine failed: %v", err)
+		}
+		machine.Status = pb.MachineStatus_MOVING
+	}
+	return mm
+}
+
+func TestTickAdvancesEveryShard(t *testing.T) {
+	mm := newBenchmarkFleet(t, 3*minShardSize)
+	defer mm.Close()
+
+	mm.clock.advance(1)
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	for id, machine := range mm.machines {
+		if sample := mm.machineToProto(machine); sample.Odometer == 0 {
+			t.Fatalf("machine %d was not advanced: %v", id, sample)
+		}
+	}
+}
+
+func BenchmarkTick(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprintf("machines=%d", n), func(b *testing.B) {
+			mm := newBenchmarkFleet(b, n)
+			defer mm.Close()
+
+			now := time.Now()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				now = now.Add(mm.updateRate)
+				mm.tick(clockTick{number: uint64(i + 1), now: now, elapsed: mm.updateRate})
+			}
+			b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "machines/s")
+		})
+	}
+}
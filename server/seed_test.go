@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// seededTrajectories runs a seeded fleet without movement goroutines and records every step
+func seededTrajectories(t *testing.T, seed uint64) string {
+	mm := NewMachineManager(WithSeed(seed))
+	defer mm.Close()
+
+	var machines []*Machine
+	for modelType := range len(pb.MotionModelType_name) {
+		machine, err := mm.createMachine(&pb.CreateMachineRequest{MotionModel: pb.MotionModelType(modelType)})
+		if err != nil {
+			t.Fatalf("createMachine failed: %v", err)
+		}
+		machine.Status = pb.MachineStatus_MOVING
+		machines = append(machines, machine)
+	}
+
+	var out strings.Builder
+	now := time.Unix(0, 0)
+	for step := 1; step <= 20; step++ {
+		now = now.Add(mm.updateRate)
+		for _, machine := range machines {
+			mm.advanceMachine(machine, mm.updateRate, now)
+			fmt.Fprintf(&out, "step=%d id=%d lat=%.7f lon=%.7f alt=%.2f fuel=%.1f\n",
+				step, machine.ID, machine.Location.Lat, machine.Location.Lon, machine.Location.Alt, machine.FuelLevel)
+		}
+	}
+	return out.String()
+}
+
+func TestSeededTrajectoriesGolden(t *testing.T) {
+	got := seededTrajectories(t, 42)
+	golden := filepath.Join("testdata", "seeded_trajectories.golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("seeded trajectories differ from %s; rerun with -update if the change is intended", golden)
+	}
+}
+
+func TestSeededTrajectoriesDiffer(t *testing.T) {
+	if seededTrajectories(t, 1) == seededTrajectories(t, 2) {
+		t.Errorf("different seeds produced identical trajectories")
+	}
+}
+
+func TestCreateMachineSeed(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	seed := uint64(7)
+	machine, err := mm.CreateMachine(context.Background(), &pb.CreateMachineRequest{Seed: &seed})
+	if err != nil {
+		t.Fatalf("CreateMachine failed: %v", err)
+	}
+	if machine.Seed != seed {
+		t.Errorf("got seed %d, want %d", machine.Seed, seed)
+	}
+}
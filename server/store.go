@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Store persists fleet state across restarts
+type Store interface {
+	// Load returns the snapshot and the logged commands after it, as recovered when the store was opened.
+	// The snapshot is nil when nothing was saved yet
+	Load() (*pb.FleetState, []*pb.WALRecord)
+	// Save replaces the snapshot, stamping it with the last logged command it reflects
+	Save(state *pb.FleetState) error
+	// Append logs a command, assigning its sequence number. A no-op when commands are not logged
+	Append(record *pb.WALRecord) error
+	Close() error
+}
+
+// FileStore keeps the snapshot in a single file, written as JSON when the path ends in .json and
+// as protobuf otherwise. Commands are logged to an append-only, length-delimited protobuf file
+// next to it, named after the snapshot with a .wal suffix
+type FileStore struct {
+	mu       sync.Mutex
+	path     string
+	asJSON   bool
+	wal      *os.File // nil when commands are not logged
+	sequence uint64   // sequence number of the last logged command
+	state    *pb.FleetState
+	records  []*pb.WALRecord
+}
+
+// OpenFileStore reads any saved snapshot and write-ahead log at path, opening the log
+// for appending when logCommands is set
+func OpenFileStore(path string, logCommands bool) (*FileStore, error) {
+	s := &FileStore{path: path, asJSON: filepath.Ext(path) == ".json"}
+
+	if err := s.readSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.readLog(); err != nil {
+		return nil, err
+	}
+
+	if logCommands {
+		wal, err := os.OpenFile(s.walPath(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		s.wal = wal
+	}
+	return s, nil
+}
+
+func (s *FileStore) walPath() string {
+	return s.path + ".wal"
+}
+
+func (s *FileStore) readSnapshot() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state := &pb.FleetState{}
+	if s.asJSON {
+		err = protojson.Unmarshal(data, state)
+	} else {
+		err = proto.Unmarshal(data, state)
+	}
+	if err != nil {
+		return fmt.Errorf("reading snapshot %s: %w", s.path, err)
+	}
+	s.state = state
+	s.sequence = state.WalSequence
+	return nil
+}
+
+// readLog keeps the logged commands the snapshot does not reflect yet. A record torn by a crash
+// mid-write ends the log and is truncated away
+func (s *FileStore) readLog() error {
+	data, err := os.ReadFile(s.walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	reader := bytes.NewReader(data)
+	for {
+		valid := int64(len(data) - reader.Len())
+		record := &pb.WALRecord{}
+		err := protodelim.UnmarshalFrom(reader, record)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Truncating write-ahead log %s at byte %d: %v", s.walPath(), valid, err)
+			return os.Truncate(s.walPath(), valid)
+		}
+		if record.Sequence <= s.sequence {
+			continue
+		}
+		s.records = append(s.records, record)
+		s.sequence = record.Sequence
+	}
+}
+
+func (s *FileStore) Load() (*pb.FleetState, []*pb.WALRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, s.records
+}
+
+// Save writes the snapshot to a temporary file and renames it into place, so a crash never
+// leaves a partial snapshot. The log is then emptied, its commands are all in the snapshot
+func (s *FileStore) Save(state *pb.FleetState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.WalSequence = s.sequence
+	var data []byte
+	var err error
+	if s.asJSON {
+		data, err = protojson.MarshalOptions{Multiline: true}.Marshal(state)
+	} else {
+		data, err = proto.Marshal(state)
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	if s.wal == nil {
+		// A log left by an earlier run is reflected in the snapshot now
+		if err := os.Remove(s.walPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := s.wal.Truncate(0); err != nil {
+		return err
+	}
+	return s.wal.Sync()
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Append writes the command and syncs it to disk before returning
+func (s *FileStore) Append(record *pb.WALRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	s.sequence++
+	record.Sequence = s.sequence
+	if _, err := protodelim.MarshalTo(s.wal, record); err != nil {
+		return err
+	}
+	return s.wal.Sync()
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.Close()
+}
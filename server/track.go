@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	pb "stream-machine-map-monitor/proto"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultTrackLength = 600 // ten minutes of history at one tick per second
+
+// trackPoint is one sample of a machine's history, kept compact since every machine holds many
+type trackPoint struct {
+	at       time.Time
+	lat, lon float64
+	alt      float32
+	fuel     float32
+	status   pb.MachineStatus
+}
+
+// Track is a bounded ring buffer of a machine's recent positions. Guarded by machine.mutex
+type Track struct {
+	points []trackPoint // grows up to capacity, then wraps
+	start  int          // index of the oldest point once the buffer has wrapped
+	size   int          // capacity, 0 disables the track
+}
+
+func newTrack(size int) *Track {
+	return &Track{size: size}
+}
+
+// WithTrackLength sets how many points of position history each machine keeps, 0 keeps none
+func WithTrackLength(points int) ManagerOption {
+	return func(mm *MachineManager) {
+		mm.trackLength = points
+	}
+}
+
+// record samples the machine at time at. Samples that match the latest point are skipped, so
+// stationary machines do not push their history out. Caller must hold machine.mutex
+func (t *Track) record(at time.Time, m *Machine) {
+	if t.size == 0 {
+		return
+	}
+	point := trackPoint{
+		at:     at,
+		lat:    m.Location.Lat,
+		lon:    m.Location.Lon,
+		alt:    m.Location.Alt,
+		fuel:   m.FuelLevel,
+		status: m.Status,
+	}
+	if len(t.points) > 0 {
+		latest := t.points[(t.start+len(t.points)-1)%len(t.points)]
+		latest.at = at
+		if latest == point {
+			return
+		}
+	}
+
+	if len(t.points) < t.size {
+		t.points = append(t.points, point)
+		return
+	}
+	t.points[t.start] = point
+	t.start = (t.start + 1) % t.size
+}
+
+// between returns the points sampled in [since, until], oldest first. The point in effect at since
+// is included, so the machine's position is known for the whole range
+func (t *Track) between(since, until time.Time) []trackPoint {
+	var points []trackPoint
+	for i := range t.points {
+		point := t.points[(t.start+i)%len(t.points)]
+		if point.at.After(until) {
+			break
+		}
+		if point.at.After(since) || len(points) == 0 {
+			points = append(points, point)
+			continue
+		}
+		// Still before since, this point supersedes the one kept so far
+		points[0] = point
+	}
+	return points
+}
+
+// downsample keeps at most one point per interval, then thins evenly to maxPoints, always keeping
+// the first and last points
+func downsample(points []trackPoint, interval time.Duration, maxPoints int) []trackPoint {
+	if interval > 0 && len(points) > 0 {
+		kept := []trackPoint{points[0]}
+		for _, point := range points[1:] {
+			if point.at.Sub(kept[len(kept)-1].at) >= interval {
+				kept = append(kept, point)
+			}
+		}
+		if last := points[len(points)-1]; kept[len(kept)-1].at != last.at {
+			kept = append(kept, last)
+		}
+		points = kept
+	}
+
+	if maxPoints == 0 || len(points) <= maxPoints {
+		return points
+	}
+	if maxPoints == 1 {
+		return points[len(points)-1:]
+	}
+	kept := make([]trackPoint, maxPoints)
+	for i := range kept {
+		kept[i] = points[i*(len(points)-1)/(maxPoints-1)]
+	}
+	return kept
+}
+
+func (p trackPoint) toProto() *pb.TrackPoint {
+	return &pb.TrackPoint{
+		Timestamp: timestamppb.New(p.at),
+		Location:  &pb.GPS{Lat: p.lat, Lon: p.lon, Alt: p.alt},
+		FuelLevel: p.fuel,
+		Status:    p.status,
+	}
+}
+
+// gRPC method to fetch where a machine has been, in simulation time, optionally downsampled
+func (mm *MachineManager) GetTrack(ctx context.Context, req *pb.GetTrackRequest) (*pb.GetTrackResponse, error) {
+	machine, err := mm.lookupMachine(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	since, until := time.Time{}, time.Unix(1<<62, 0)
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		until = req.Until.AsTime()
+	}
+	if since.After(until) {
+		return nil, status.Error(codes.InvalidArgument, "since is after until")
+	}
+	if req.MinInterval.AsDuration() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "min_interval must not be negative")
+	}
+
+	machine.mutex.RLock()
+	points := machine.track.between(since, until)
+	machine.mutex.RUnlock()
+
+	points = downsample(points, req.MinInterval.AsDuration(), int(req.MaxPoints))
+	resp := &pb.GetTrackResponse{Id: machine.ID, Points: make([]*pb.TrackPoint, len(points))}
+	for i, point := range points {
+		resp.Points[i] = point.toProto()
+	}
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// trackAt builds a track with one point per second from t=1s, each a step further north
+func trackAt(size, points int) *Track {
+	track := newTrack(size)
+	machine := &Machine{Location: &pb.GPS{}}
+	for i := 1; i <= points; i++ {
+		machine.Location.Lat = float64(i)
+		track.record(time.Unix(int64(i), 0), machine)
+	}
+	return track
+}
+
+func pointSeconds(points []trackPoint) []int64 {
+	seconds := make([]int64, len(points))
+	for i, point := range points {
+		seconds[i] = point.at.Unix()
+	}
+	return seconds
+}
+
+func TestTrackKeepsLatestPoints(t *testing.T) {
+	track := trackAt(3, 5)
+	got := pointSeconds(track.between(time.Time{}, time.Unix(100, 0)))
+	if want := []int64{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("got points at %v, want %v", got, want)
+	}
+
+	// A stationary machine does not push history out
+	machine := &Machine{Location: &pb.GPS{Lat: 5}}
+	track.record(time.Unix(6, 0), machine)
+	if got := pointSeconds(track.between(time.Time{}, time.Unix(100, 0))); !slices.Equal(got, []int64{3, 4, 5}) {
+		t.Errorf("got points at %v after an unchanged sample, want no new point", got)
+	}
+}
+
+func TestTrackBetween(t *testing.T) {
+	track := trackAt(10, 10)
+	tests := []struct {
+		name         string
+		since, until int64
+		want         []int64
+	}{
+		{"range", 4, 6, []int64{4, 5, 6}},
+		{"point in effect at since", 0, 2, []int64{1, 2}},
+		{"since between points", 100, 200, []int64{10}},
+		{"until before the track", -5, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pointSeconds(track.between(time.Unix(tt.since, 0), time.Unix(tt.until, 0)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got points at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	points := trackAt(20, 10).between(time.Time{}, time.Unix(100, 0))
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		maxPoints int
+		want      []int64
+	}{
+		{"none", 0, 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"max points", 0, 4, []int64{1, 4, 7, 10}},
+		{"single point", 0, 1, []int64{10}},
+		{"interval", 4 * time.Second, 0, []int64{1, 5, 9, 10}},
+		{"interval then max points", 2 * time.Second, 3, []int64{1, 5, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pointSeconds(downsample(points, tt.interval, tt.maxPoints))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got points at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTrack(t *testing.T) {
+	mm, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	mm.PauseSimulation(ctx, &pb.PauseSimulationRequest{})
+
+	machine, _ := mm.CreateMachine(ctx, &pb.CreateMachineRequest{MotionModel: pb.MotionModelType_DEAD_RECKONING})
+	mm.UnPause(ctx, &pb.Machine{Id: machine.Id})
+	mm.StepSimulation(ctx, &pb.StepSimulationRequest{Ticks: 10})
+
+	track, err := mm.GetTrack(ctx, &pb.GetTrackRequest{Id: machine.Id})
+	if err != nil {
+		t.Fatalf("GetTrack failed: %v", err)
+	}
+	if len(track.Points) != 11 {
+		t.Fatalf("got %d points, want the spawn point and one per tick", len(track.Points))
+	}
+	if track.Points[0].Location.Lat != machine.Location.Lat || track.Points[10].FuelLevel >= 100 {
+		t.Errorf("got track from %v to %v, want it to start at the spawn point and burn fuel", track.Points[0], track.Points[10])
+	}
+
+	since := track.Points[0].Timestamp.AsTime().Add(5 * time.Second)
+	recent, _ := mm.GetTrack(ctx, &pb.GetTrackRequest{
+		Id:          machine.Id,
+		Since:       timestamppb.New(since),
+		MinInterval: durationpb.New(2 * time.Second),
+	})
+	if len(recent.Points) != 4 || !recent.Points[0].Timestamp.AsTime().Equal(since) {
+		t.Errorf("got %v, want every other point of the last five seconds and the latest", recent.Points)
+	}
+
+	_, err = mm.GetTrack(ctx, &pb.GetTrackRequest{Id: machine.Id, Since: timestamppb.New(since), Until: timestamppb.New(since.Add(-time.Second))})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("since after until: got %v, want InvalidArgument", err)
+	}
+	if _, err := mm.GetTrack(ctx, &pb.GetTrackRequest{Id: 99}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown machine: got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"slices"
+	pb "stream-machine-map-monitor/proto"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	commandTimeout       = 5 * time.Second // how long one command may take before the browser gets DeadlineExceeded
+	maxRememberedReplies = 1024            // outcomes kept for retried commands, oldest forgotten first
+)
+
+// commandLog remembers the outcome of recent commands by their browser supplied request_id, so a
+// retried command is answered with the first outcome instead of being applied twice. It is shared
+// by every connection, as a retry often arrives on a new socket after the old one dropped
+type commandLog struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	replies map[string]*commandReply
+	order   []string // request ids, oldest first
+}
+
+type commandReply struct {
+	key     commandKey
+	done    chan struct{} // closed once machine and err are set
+	machine *pb.Machine
+	err     error
+}
+
+// commandKey is a command with its arguments. A request id stands for exactly one, so reusing it
+// for another machine or rate is refused rather than answered with the first outcome
+type commandKey struct {
+	command string
+	args    string // the arguments, as text
+}
+
+func newCommandLog() *commandLog {
+	return &commandLog{timeout: commandTimeout, replies: make(map[string]*commandReply)}
+}
+
+// run applies a command within the command timeout. A request id seen before gets the first
+// outcome, waiting for it if that command is still running. Commands without a request id always
+// run. A command runs to completion even if its browser disconnects, so the retry learns whether
+// it was applied. Only successes and errors a retry would get again are remembered, so a command
+// that timed out or found the server unavailable is applied again when retried
+func (l *commandLog) run(ctx context.Context, requestID string, key commandKey, apply func(context.Context) (*pb.Machine, error)) (*pb.Machine, error) {
+	if requestID == "" {
+		return l.apply(ctx, apply)
+	}
+
+	l.mu.Lock()
+	reply, seen := l.replies[requestID]
+	if !seen {
+		reply = &commandReply{key: key, done: make(chan struct{})}
+		l.remember(requestID, reply)
+	}
+	l.mu.Unlock()
+
+	if seen {
+		if reply.key != key {
+			return nil, status.Errorf(codes.InvalidArgument, "request_id %q was already used for a different %s", requestID, reply.key.command)
+		}
+		select {
+		case <-reply.done:
+			return reply.machine, reply.err
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	reply.machine, reply.err = l.apply(ctx, apply)
+	if reply.err != nil && !definitive(reply.err) {
+		l.mu.Lock()
+		l.forget(requestID, reply)
+		l.mu.Unlock()
+	}
+	close(reply.done)
+	return reply.machine, reply.err
+}
+
+// definitive reports whether a retry of the failed command would fail the same way
+func definitive(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func (l *commandLog) apply(ctx context.Context, apply func(context.Context) (*pb.Machine, error)) (*pb.Machine, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.timeout)
+	defer cancel()
+	return apply(ctx)
+}
+
+// remember records a reply, forgetting the oldest once full. Callers hold mu
+func (l *commandLog) remember(requestID string, reply *commandReply) {
+	if len(l.order) >= maxRememberedReplies {
+		delete(l.replies, l.order[0])
+		l.order = l.order[1:]
+	}
+	l.replies[requestID] = reply
+	l.order = append(l.order, requestID)
+}
+
+// forget drops a reply, unless the request id has since been reused. Callers hold mu
+func (l *commandLog) forget(requestID string, reply *commandReply) {
+	if l.replies[requestID] != reply {
+		return
+	}
+	delete(l.replies, requestID)
+	if i := slices.Index(l.order, requestID); i >= 0 {
+		l.order = slices.Delete(l.order, i, i+1)
+	}
+}
+
+// machineCommand returns the call applying a command to machine id, the same on every transport.
+// rate is the refuel rate, 0 refuels instantly
+func machineCommand(client pb.MachineMapClient, command string, id uint32, rate float32) (func(context.Context) (*pb.Machine, error), error) {
+	switch command {
+	case "pause":
+		return func(ctx context.Context) (*pb.Machine, error) { return client.Pause(ctx, &pb.Machine{Id: id}) }, nil
+	case "unpause":
+		return func(ctx context.Context) (*pb.Machine, error) { return client.UnPause(ctx, &pb.Machine{Id: id}) }, nil
+	case "refuel":
+		return func(ctx context.Context) (*pb.Machine, error) {
+			return client.Refuel(ctx, &pb.RefuelRequest{Id: id, Rate: rate})
+		}, nil
+	case "delete":
+		return func(ctx context.Context) (*pb.Machine, error) { return client.DeleteMachine(ctx, &pb.Machine{Id: id}) }, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unknown request type %q", command)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCommandLogAppliesOncePerRequestID(t *testing.T) {
+	l := newCommandLog()
+	applied := 0
+	apply := func(context.Context) (*pb.Machine, error) {
+		applied++
+		return &pb.Machine{Id: uint32(applied)}, nil
+	}
+
+	for range 3 {
+		if machine, err := l.run(context.Background(), "r1", commandKey{command: "create"}, apply); err != nil || machine.Id != 1 {
+			t.Errorf("got %v, %v, want the first machine", machine, err)
+		}
+	}
+	l.run(context.Background(), "", commandKey{command: "create"}, apply)
+	l.run(context.Background(), "", commandKey{command: "create"}, apply)
+	if applied != 3 {
+		t.Errorf("applied %d times, want once for r1 and every time without an id", applied)
+	}
+}
+
+func TestCommandLogRetryWaitsForRunningCommand(t *testing.T) {
+	l := newCommandLog()
+	started, release := make(chan struct{}), make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		_, err := l.run(context.Background(), "r1", commandKey{command: "pause"}, func(context.Context) (*pb.Machine, error) {
+			close(started)
+			<-release
+			return &pb.Machine{Id: 1, IsPaused: true}, nil
+		})
+		first <- err
+	}()
+
+	// Retry while the first command is still running
+	<-started
+	retried := make(chan *pb.Machine, 1)
+	go func() {
+		machine, _ := l.run(context.Background(), "r1", commandKey{command: "pause"}, func(context.Context) (*pb.Machine, error) {
+			t.Error("retry applied the command again")
+			return nil, nil
+		})
+		retried <- machine
+	}()
+	close(release)
+
+	if err := <-first; err != nil {
+		t.Errorf("first run failed: %v", err)
+	}
+	if machine := <-retried; !machine.GetIsPaused() {
+		t.Errorf("got %v, want the first outcome", machine)
+	}
+}
+
+func TestCommandLogRefusesReusedRequestID(t *testing.T) {
+	l := newCommandLog()
+	apply := func(context.Context) (*pb.Machine, error) { return &pb.Machine{Id: 1, IsPaused: true}, nil }
+	l.run(context.Background(), "r1", commandKey{command: "pause", args: "id=1"}, apply)
+
+	for _, key := range []commandKey{{command: "pause", args: "id=2"}, {command: "unpause", args: "id=1"}} {
+		if _, err := l.run(context.Background(), "r1", key, apply); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got %v, want the reused request id refused", key, err)
+		}
+	}
+}
+
+func TestCommandLogRetriesAfterUnavailable(t *testing.T) {
+	l := newCommandLog()
+	key := commandKey{command: "pause", args: "id=1"}
+	applied := 0
+	apply := func(context.Context) (*pb.Machine, error) {
+		if applied++; applied == 1 {
+			return nil, status.Error(codes.Unavailable, "server restarting")
+		}
+		return &pb.Machine{Id: 1, IsPaused: true}, nil
+	}
+
+	if _, err := l.run(context.Background(), "r1", key, apply); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	if machine, err := l.run(context.Background(), "r1", key, apply); err != nil || !machine.GetIsPaused() {
+		t.Errorf("got %v, %v, want the retry applied", machine, err)
+	}
+	if machine, _ := l.run(context.Background(), "r1", key, apply); applied != 2 || !machine.GetIsPaused() {
+		t.Errorf("applied %d times, want the success remembered", applied)
+	}
+
+	notFound := func(context.Context) (*pb.Machine, error) {
+		applied++
+		return nil, status.Error(codes.NotFound, "machine 9 not found")
+	}
+	for range 2 {
+		l.run(context.Background(), "r2", key, notFound)
+	}
+	if applied != 3 {
+		t.Errorf("applied %d times, want NotFound remembered", applied)
+	}
+}
+
+func TestCommandLogTimesOut(t *testing.T) {
+	l := newCommandLog()
+	l.timeout = 20 * time.Millisecond
+
+	// The command outlives its caller, only the command timeout ends it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := l.run(ctx, "r1", commandKey{command: "delete"}, func(ctx context.Context) (*pb.Machine, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestCommandLogForgetsOldest(t *testing.T) {
+	l := newCommandLog()
+	apply := func(context.Context) (*pb.Machine, error) { return &pb.Machine{}, nil }
+	for i := range maxRememberedReplies + 1 {
+		l.run(context.Background(), strconv.Itoa(i), commandKey{command: "pause"}, apply)
+	}
+	if len(l.replies) != maxRememberedReplies || len(l.order) != maxRememberedReplies {
+		t.Errorf("remembering %d replies, want at most %d", len(l.replies), maxRememberedReplies)
+	}
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	pb "stream-machine-map-monitor/proto"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fleetProtocolV1 is the WebSocket subprotocol naming the /fleet protocol version. Clients that ask
+// for no subprotocol get v1 too
+const fleetProtocolV1 = "machine-map.v1"
+
+// maxQueuedCommands is how many commands a session holds while one is running, further ones fail
+// with ResourceExhausted
+const maxQueuedCommands = 16
+
+var fleetUpgrader = websocket.Upgrader{
+	CheckOrigin:  upgrader.CheckOrigin,
+	Subprotocols: []string{fleetProtocolV1},
+}
+
+// Frames sent on /fleet, each tagged with its type
+type telemetryFrame struct {
+	Type     string        `json:"type"` // always "telemetry"
+	Machines []*pb.Machine `json:"machines,omitempty"`
+	Removed  []uint32      `json:"removed,omitempty"` // deleted machines, no longer subscribed
+}
+
+type ackFrame struct {
+	Type      string      `json:"type"`                 // always "ack"
+	RequestID string      `json:"request_id,omitempty"` // echoed from the command
+	Command   string      `json:"command"`
+	Machine   *pb.Machine `json:"machine,omitempty"` // state after create, delete, pause, unpause and refuel
+	IDs       []uint32    `json:"ids,omitempty"`     // machines subscribed or unsubscribed
+	All       bool        `json:"all,omitempty"`
+}
+
+type eventFrame struct {
+	Type  string          `json:"type"` // always "event"
+	Event json.RawMessage `json:"event"`
+}
+
+// eventJSON encodes events with the proto field names and numeric enums, matching machines
+var eventJSON = protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true}
+
+// machineSelector is either a list of machine ids or the string "all"
+type machineSelector struct {
+	all bool
+	ids []uint32
+}
+
+func (m *machineSelector) UnmarshalJSON(data []byte) error {
+	var all string
+	if err := json.Unmarshal(data, &all); err == nil {
+		if all != "all" {
+			return fmt.Errorf(`ids must be a list or "all", got %q`, all)
+		}
+		m.all = true
+		return nil
+	}
+	return json.Unmarshal(data, &m.ids)
+}
+
+// fleetCommand is a message from the browser
+type fleetCommand struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id"` // echoed in the ack or error, and dedupes retries
+	IDs       machineSelector `json:"ids"`        // subscribe and unsubscribe
+	ID        uint32          `json:"id"`         // delete, pause, unpause and refuel
+	Rate      float32         `json:"rate"`       // refuel only, 0 refuels instantly
+	Motion    string          `json:"motion"`     // create only, e.g. "dead_reckoning"
+	Speed     float64         `json:"speed"`      // create only
+	Heading   float64         `json:"heading"`    // create only
+}
+
+// fleetSession is one browser on /fleet. It caches the latest state of every machine from a
+// single WatchFleet stream and forwards the subscribed ones. The session outlives its socket for
+// a grace window, so a browser that reconnects keeps its subscriptions and gets what it missed.
+// Only the session goroutine uses it
+type fleetSession struct {
+	token      string
+	client     pb.MachineMapClient
+	commands   *commandLog
+	registry   *sessionRegistry
+	cancel     context.CancelFunc // ends the gRPC streams
+	machines   map[uint32]*pb.Machine
+	all        bool
+	subscribed map[uint32]bool
+
+	conn        *fleetConn      // nil while the browser is away
+	attachments chan *fleetConn // connections handed over by handleFleet and handleEvents
+	done        chan struct{}   // closed once the session has ended
+	resumable   bool            // a connection was attached before, so the next one resumes
+	seq         uint64          // frames sent, numbering them
+	history     []numberedFrame // the last frames sent, oldest first
+	seen        uint64          // last frame the browser had when it last attached
+	gone        map[uint32]bool // subscribed machines removed while the browser was away
+
+	jobs    chan commandJob // commands waiting for their RPCs, run one at a time by work
+	results chan func()     // finished commands, applied by the session goroutine
+}
+
+// commandJob makes the RPCs of one command off the session goroutine, returning what to do with
+// the outcome back on it
+type commandJob func(ctx context.Context) func()
+
+func (s *fleetSession) isSubscribed(id uint32) bool {
+	return s.all || s.subscribed[id]
+}
+
+// handleFleet serves many machines over one WebSocket. The browser subscribes to machines by id
+// or to all of them, and sends commands on the same socket. Server frames are "session" first,
+// then "telemetry", "ack", "error" or "event", each numbered by seq. A browser reconnecting with
+// ?session=<token>&seq=<last seq it got> resumes its session if still within the grace window,
+// getting the frames it missed, otherwise it gets a new one
+func (s *ProxyServer) handleFleet(w http.ResponseWriter, r *http.Request) {
+	if requested := websocket.Subprotocols(r); len(requested) > 0 && !slices.Contains(requested, fleetProtocolV1) {
+		http.Error(w, "unsupported protocol, want "+fleetProtocolV1, http.StatusBadRequest)
+		return
+	}
+	conn, err := fleetUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	writer := newConnWriter(conn)
+	defer writer.close()
+	c := newFleetConn(conn, writer, s.sessions.pongWait)
+	if seen, err := strconv.ParseUint(r.URL.Query().Get("seq"), 10, 64); err == nil {
+		c.seen = &seen
+	}
+
+	if err := s.joinSession(r.URL.Query().Get("session"), machineSelector{}, c); err != nil {
+		writer.sendError("stream", err)
+		return
+	}
+	<-c.detached
+}
+
+// joinSession attaches c to the session named by token, or to a new session subscribed to
+// selector when that one has expired
+func (s *ProxyServer) joinSession(token string, selector machineSelector, c *fleetConn) error {
+	if session := s.sessions.get(token); session != nil && session.attach(c) {
+		return nil
+	}
+	c.seen = nil
+	session, err := s.startFleetSession(selector)
+	if err != nil {
+		return err
+	}
+	if !session.attach(c) {
+		return status.Error(codes.Unavailable, "session ended")
+	}
+	return nil
+}
+
+// startFleetSession opens the gRPC streams for a new session and starts it
+func (s *ProxyServer) startFleetSession(selector machineSelector) (*fleetSession, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fleet, err := s.grpcClient.WatchFleet(ctx, &pb.WatchFleetRequest{})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	updates := make(chan *pb.FleetUpdate, 16)
+	streamErrs := make(chan error, 1)
+	go receive(ctx, fleet.Recv, updates, func(err error) { streamErrs <- err })
+
+	// Events are optional, a replay server does not serve them
+	events := make(chan *pb.Event, 16)
+	if watch, err := s.grpcClient.WatchEvents(ctx, &pb.WatchEventsRequest{}); err != nil {
+		log.Printf("Not forwarding events: %v", err)
+	} else {
+		go receive(ctx, watch.Recv, events, func(err error) {
+			if status.Code(err) != codes.Canceled {
+				log.Printf("Event stream ended: %v", err)
+			}
+		})
+	}
+
+	session := &fleetSession{
+		token:       newSessionToken(),
+		client:      s.grpcClient,
+		commands:    s.commands,
+		registry:    s.sessions,
+		cancel:      cancel,
+		machines:    make(map[uint32]*pb.Machine),
+		all:         selector.all,
+		subscribed:  make(map[uint32]bool),
+		attachments: make(chan *fleetConn),
+		done:        make(chan struct{}),
+		gone:        make(map[uint32]bool),
+		jobs:        make(chan commandJob, maxQueuedCommands),
+		results:     make(chan func()),
+	}
+	for _, id := range selector.ids {
+		session.subscribed[id] = true
+	}
+	s.sessions.add(session)
+	go session.run(ctx, updates, events, streamErrs)
+	go session.work(ctx)
+	return session, nil
+}
+
+// run serves the session until its stream fails or the browser stays away past the grace window
+func (s *fleetSession) run(ctx context.Context, updates <-chan *pb.FleetUpdate, events <-chan *pb.Event, streamErrs <-chan error) {
+	defer s.end()
+
+	// Commands wait for the first fleet update, so subscriptions can be checked against it
+	ready := false
+	var waiting [][]byte
+	var grace <-chan time.Time
+	for {
+		var messages chan []byte
+		if s.conn != nil {
+			messages = s.conn.messages
+		}
+		if s.conn == nil && grace == nil {
+			grace = time.After(s.registry.grace)
+		}
+
+		select {
+		case update := <-updates:
+			s.applyFleet(update)
+			if !ready {
+				ready = true
+				for _, message := range waiting {
+					s.handle(ctx, message)
+				}
+				waiting = nil
+			}
+		case event := <-events:
+			s.forwardEvent(event)
+		case done := <-s.results:
+			done()
+		case c := <-s.attachments:
+			s.attached(c)
+			grace = nil
+		case message, ok := <-messages:
+			if !ok {
+				log.Printf("Client disconnected, keeping session %s for %v", s.token, s.registry.grace)
+				s.detach()
+				continue
+			}
+			if !ready {
+				waiting = append(waiting, message)
+				continue
+			}
+			s.handle(ctx, message)
+		case err := <-streamErrs:
+			log.Printf("Fleet stream ended: %v", err)
+			if s.conn != nil {
+				s.conn.writer.sendError("stream", err)
+			}
+			return
+		case <-grace:
+			log.Printf("Session %s expired, cleaning up", s.token)
+			return
+		}
+	}
+}
+
+// end forgets the session and closes its streams and socket
+func (s *fleetSession) end() {
+	s.registry.remove(s)
+	s.cancel()
+	close(s.done)
+	if s.conn != nil {
+		s.detach()
+	}
+}
+
+// work runs queued commands in order, so a slow backend never holds up the fleet updates
+func (s *fleetSession) work(ctx context.Context) {
+	for {
+		select {
+		case job := <-s.jobs:
+			done := job(ctx)
+			select {
+			case s.results <- done:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue queues a command for work, failing it when too many are waiting
+func (s *fleetSession) enqueue(requestID, command string, job commandJob) {
+	select {
+	case s.jobs <- job:
+	default:
+		s.emitError(requestID, command, status.Error(codes.ResourceExhausted, "too many commands in flight"))
+	}
+}
+
+// receive forwards a gRPC stream to a channel until it fails or ctx is done
+func receive[T any](ctx context.Context, recv func() (T, error), out chan<- T, onError func(error)) {
+	for {
+		item, err := recv()
+		if err != nil {
+			onError(err)
+			return
+		}
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// applyFleet updates the cache and forwards changes to subscribed machines
+func (s *fleetSession) applyFleet(update *pb.FleetUpdate) {
+	frame := telemetryFrame{Type: "telemetry"}
+	for _, event := range update.Events {
+		id := event.Machine.GetId()
+		if event.Type == pb.FleetEvent_REMOVED {
+			delete(s.machines, id)
+			if s.isSubscribed(id) {
+				frame.Removed = append(frame.Removed, id)
+				if s.conn == nil {
+					s.gone[id] = true
+				}
+			}
+			delete(s.subscribed, id)
+			continue
+		}
+		s.machines[id] = event.Machine
+		if s.isSubscribed(id) {
+			frame.Machines = append(frame.Machines, event.Machine)
+		}
+	}
+	if len(frame.Machines) > 0 || len(frame.Removed) > 0 {
+		s.emit(priorityTelemetry, frame)
+	}
+}
+
+func (s *fleetSession) forwardEvent(event *pb.Event) {
+	if !s.isSubscribed(event.MachineId) {
+		return
+	}
+	data, err := eventJSON.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal event: %v", err)
+		return
+	}
+	s.emit(priorityEvent, eventFrame{Type: "event", Event: data})
+}
+
+// handle runs one command from the browser, answering with an ack or an error frame
+func (s *fleetSession) handle(ctx context.Context, message []byte) {
+	var command fleetCommand
+	if err := json.Unmarshal(message, &command); err != nil {
+		log.Printf("Failed to unmarshal request: %v", err)
+		s.emitError("", "", status.Errorf(codes.InvalidArgument, "malformed request: %v", err))
+		return
+	}
+
+	var apply func(context.Context) (*pb.Machine, error)
+	switch command.Type {
+	case "subscribe":
+		s.subscribe(command.RequestID, command.IDs)
+		return
+	case "unsubscribe":
+		s.unsubscribe(command.RequestID, command.IDs)
+		return
+	case "create":
+		req, err := command.createRequest()
+		if err != nil {
+			s.emitError(command.RequestID, command.Type, err)
+			return
+		}
+		apply = func(ctx context.Context) (*pb.Machine, error) { return s.client.CreateMachine(ctx, req) }
+	default:
+		var err error
+		if apply, err = machineCommand(s.client, command.Type, command.ID, command.Rate); err != nil {
+			s.emitError(command.RequestID, command.Type, err)
+			return
+		}
+	}
+
+	s.enqueue(command.RequestID, command.Type, func(ctx context.Context) func() {
+		machine, err := s.commands.run(ctx, command.RequestID, command.key(), apply)
+		return func() {
+			if err != nil {
+				log.Printf("Failed to %s machine: %v", command.Type, err)
+				s.emitError(command.RequestID, command.Type, err)
+				return
+			}
+			if command.Type == "create" {
+				// Follow the new machine, as /machine does
+				s.machines[machine.Id] = machine
+				s.subscribed[machine.Id] = true
+			}
+			s.emit(priorityControl, ackFrame{Type: "ack", RequestID: command.RequestID, Command: command.Type, Machine: machine})
+		}
+	})
+}
+
+// emitError relays a failed command to the browser as an error frame
+func (s *fleetSession) emitError(requestID, command string, err error) {
+	frame := newErrorFrame(command, err)
+	frame.RequestID = requestID
+	s.emit(priorityControl, frame)
+}
+
+// key identifies the command with its arguments for the command log
+func (c *fleetCommand) key() commandKey {
+	return commandKey{command: c.Type, args: fmt.Sprintf("id=%d rate=%v motion=%q speed=%v heading=%v", c.ID, c.Rate, c.Motion, c.Speed, c.Heading)}
+}
+
+func (c *fleetCommand) createRequest() (*pb.CreateMachineRequest, error) {
+	req := &pb.CreateMachineRequest{Speed: c.Speed, Heading: c.Heading}
+	if c.Motion != "" {
+		modelType, err := parseMotionModel(c.Motion)
+		if err != nil {
+			return nil, err
+		}
+		req.MotionModel = modelType
+	}
+	return req, nil
+}
+
+// subscribe starts forwarding machines, sending their current state right after the ack.
+// Unknown machines fail the whole subscription
+func (s *fleetSession) subscribe(requestID string, selector machineSelector) {
+	if !selector.all && len(selector.ids) == 0 {
+		s.emitError(requestID, "subscribe", status.Error(codes.InvalidArgument, `subscribe needs ids or "all"`))
+		return
+	}
+	if selector.all {
+		s.all = true
+		s.ackSubscribe(requestID, selector, slices.SortedFunc(maps.Values(s.machines), func(a, b *pb.Machine) int { return cmp.Compare(a.Id, b.Id) }))
+		return
+	}
+
+	var missing []uint32
+	for _, id := range selector.ids {
+		if _, ok := s.machines[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		s.subscribeIDs(requestID, selector, nil)
+		return
+	}
+
+	// Not in the cache yet, or not at all. The server has the final word
+	s.enqueue(requestID, "subscribe", func(ctx context.Context) func() {
+		ctx, cancel := context.WithTimeout(ctx, s.commands.timeout)
+		defer cancel()
+		fetched := make(map[uint32]*pb.Machine)
+		for _, id := range missing {
+			machine, err := s.client.GetMachine(ctx, &pb.Machine{Id: id})
+			if err != nil {
+				return func() { s.emitError(requestID, "subscribe", err) }
+			}
+			fetched[id] = machine
+		}
+		return func() { s.subscribeIDs(requestID, selector, fetched) }
+	})
+}
+
+// subscribeIDs subscribes to machines by id once each is cached or fetched
+func (s *fleetSession) subscribeIDs(requestID string, selector machineSelector, fetched map[uint32]*pb.Machine) {
+	var current []*pb.Machine
+	for _, id := range selector.ids {
+		machine, ok := s.machines[id]
+		if !ok {
+			if machine, ok = fetched[id]; !ok {
+				// Removed while the others were fetched
+				s.emitError(requestID, "subscribe", status.Errorf(codes.NotFound, "machine %d not found", id))
+				return
+			}
+		}
+		current = append(current, machine)
+	}
+	for _, machine := range current {
+		s.machines[machine.Id] = machine
+		s.subscribed[machine.Id] = true
+	}
+	s.ackSubscribe(requestID, selector, current)
+}
+
+// ackSubscribe acks a subscription, sending the current state of the machines right after
+func (s *fleetSession) ackSubscribe(requestID string, selector machineSelector, current []*pb.Machine) {
+	s.emit(priorityControl, ackFrame{Type: "ack", RequestID: requestID, Command: "subscribe", IDs: selector.ids, All: selector.all})
+	if len(current) > 0 {
+		// Sent as control so the initial state is never dropped
+		s.emit(priorityControl, telemetryFrame{Type: "telemetry", Machines: current})
+	}
+}
+
+// unsubscribe stops forwarding machines. Unsubscribing "all" drops every subscription, while
+// unsubscribing ids leaves a subscription to all in place
+func (s *fleetSession) unsubscribe(requestID string, selector machineSelector) {
+	if !selector.all && len(selector.ids) == 0 {
+		s.emitError(requestID, "unsubscribe", status.Error(codes.InvalidArgument, `unsubscribe needs ids or "all"`))
+		return
+	}
+
+	if selector.all {
+		s.all = false
+		clear(s.subscribed)
+	}
+	for _, id := range selector.ids {
+		delete(s.subscribed, id)
+	}
+	s.emit(priorityControl, ackFrame{Type: "ack", RequestID: requestID, Command: "unsubscribe", IDs: selector.ids, All: selector.all})
+}
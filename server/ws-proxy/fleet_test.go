@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream serves a server stream from a channel
+type fakeStream[T any] struct {
+	grpc.ClientStream
+	ctx   context.Context
+	items chan *T
+}
+
+func (f *fakeStream[T]) Recv() (*T, error) {
+	select {
+	case item := <-f.items:
+		return item, nil
+	case <-f.ctx.Done():
+		return nil, status.FromContextError(f.ctx.Err()).Err()
+	}
+}
+
+// fakeClient stands in for the gRPC server, knowing only the machines it is given
+type fakeClient struct {
+	pb.MachineMapClient
+	fleet    chan *pb.FleetUpdate
+	events   chan *pb.Event
+	machines map[uint32]*pb.Machine
+	commands chan string
+}
+
+func newFakeClient(machines ...*pb.Machine) *fakeClient {
+	f := &fakeClient{
+		fleet:    make(chan *pb.FleetUpdate, 8),
+		events:   make(chan *pb.Event, 8),
+		machines: make(map[uint32]*pb.Machine),
+		commands: make(chan string, 8),
+	}
+	for _, machine := range machines {
+		f.machines[machine.Id] = machine
+	}
+	return f
+}
+
+func (f *fakeClient) WatchFleet(ctx context.Context, req *pb.WatchFleetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.FleetUpdate], error) {
+	return &fakeStream[pb.FleetUpdate]{ctx: ctx, items: f.fleet}, nil
+}
+
+func (f *fakeClient) WatchEvents(ctx context.Context, req *pb.WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.Event], error) {
+	return &fakeStream[pb.Event]{ctx: ctx, items: f.events}, nil
+}
+
+func (f *fakeClient) GetMachine(ctx context.Context, req *pb.Machine, opts ...grpc.CallOption) (*pb.Machine, error) {
+	if machine, ok := f.machines[req.Id]; ok {
+		return machine, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "machine %d not found", req.Id)
+}
+
+func (f *fakeClient) Pause(ctx context.Context, req *pb.Machine, opts ...grpc.CallOption) (*pb.Machine, error) {
+	f.commands <- "pause"
+	machine, err := f.GetMachine(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Machine{Id: machine.Id, Status: pb.MachineStatus_IDLE, IsPaused: true}, nil
+}
+
+// UnPause never answers, standing in for a backend that hangs
+func (f *fakeClient) UnPause(ctx context.Context, req *pb.Machine, opts ...grpc.CallOption) (*pb.Machine, error) {
+	f.commands <- "unpause"
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+func (f *fakeClient) CreateMachine(ctx context.Context, req *pb.CreateMachineRequest, opts ...grpc.CallOption) (*pb.Machine, error) {
+	f.commands <- "create " + req.MotionModel.String()
+	return &pb.Machine{Id: 100, Location: &pb.GPS{}}, nil
+}
+
+// fleetServer serves /fleet from a proxy backed by client. handled receives once per finished connection
+func fleetServer(t *testing.T, client pb.MachineMapClient) (proxy *ProxyServer, url string, handled chan struct{}) {
+	t.Helper()
+	proxy = &ProxyServer{grpcClient: client, commands: newCommandLog(), sessions: newSessionRegistry()}
+	handled = make(chan struct{}, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.handleFleet(w, r)
+		handled <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return proxy, "ws" + strings.TrimPrefix(server.URL, "http") + "/fleet", handled
+}
+
+func dial(t *testing.T, url string, subprotocols ...string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+// dialFleet connects to a proxy backed by client, skipping the session frame
+func dialFleet(t *testing.T, client pb.MachineMapClient, subprotocols ...string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	_, url, _ := fleetServer(t, client)
+	conn, resp, err := dial(t, url, subprotocols...)
+	if err == nil {
+		if frame := nextFrame(t, conn); frame["type"] != "session" {
+			t.Fatalf("got %v first, want the session frame", frame)
+		}
+	}
+	return conn, resp, err
+}
+
+func nextFrame(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var frame map[string]any
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	return frame
+}
+
+// frameIDs returns the ids of the machines in a telemetry frame
+func frameIDs(frame map[string]any) []float64 {
+	var ids []float64
+	machines, _ := frame["machines"].([]any)
+	for _, machine := range machines {
+		ids = append(ids, machine.(map[string]any)["id"].(float64))
+	}
+	return ids
+}
+
+func TestFleetSubscriptionsFilterTelemetry(t *testing.T) {
+	one, two := &pb.Machine{Id: 1, Location: &pb.GPS{}}, &pb.Machine{Id: 2, Location: &pb.GPS{}}
+	client := newFakeClient(one, two)
+	conn, resp, err := dialFleet(t, client, fleetProtocolV1)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != fleetProtocolV1 {
+		t.Errorf("got subprotocol %q, want %q", resp.Header.Get("Sec-WebSocket-Protocol"), fleetProtocolV1)
+	}
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_SNAPSHOT, Machine: one}, {Type: pb.FleetEvent_SNAPSHOT, Machine: two}}}
+
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": []int{2}})
+	if ack := nextFrame(t, conn); ack["type"] != "ack" || ack["command"] != "subscribe" {
+		t.Fatalf("got %v, want a subscribe ack", ack)
+	}
+	if frame := nextFrame(t, conn); frame["type"] != "telemetry" || len(frameIDs(frame)) != 1 || frameIDs(frame)[0] != 2 {
+		t.Fatalf("got %v, want the current state of machine 2", frame)
+	}
+
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: one}, {Type: pb.FleetEvent_UPDATED, Machine: two}}}
+	if frame := nextFrame(t, conn); len(frameIDs(frame)) != 1 || frameIDs(frame)[0] != 2 {
+		t.Errorf("got %v, want only machine 2", frame)
+	}
+
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_REMOVED, Machine: two}}}
+	if frame := nextFrame(t, conn); frame["type"] != "telemetry" || frame["removed"].([]any)[0] != float64(2) {
+		t.Errorf("got %v, want machine 2 removed", frame)
+	}
+
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": "all"})
+	if ack := nextFrame(t, conn); ack["all"] != true {
+		t.Fatalf("got %v, want a subscribe all ack", ack)
+	}
+	if frame := nextFrame(t, conn); len(frameIDs(frame)) != 1 || frameIDs(frame)[0] != 1 {
+		t.Errorf("got %v, want the remaining machine 1", frame)
+	}
+
+	client.events <- &pb.Event{Id: 5, MachineId: 1, Detail: &pb.Event_Alert{Alert: &pb.Alert{Rule: pb.AlertRule_NO_MOVEMENT}}}
+	frame := nextFrame(t, conn)
+	event, _ := frame["event"].(map[string]any)
+	if frame["type"] != "event" || event["machine_id"] != float64(1) || event["alert"].(map[string]any)["rule"] != float64(2) {
+		t.Errorf("got %v, want the alert event", frame)
+	}
+}
+
+func TestFleetCommands(t *testing.T) {
+	client := newFakeClient(&pb.Machine{Id: 1, Location: &pb.GPS{}})
+	conn, _, err := dialFleet(t, client)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client.fleet <- &pb.FleetUpdate{}
+
+	// A retry with the same request id gets the same ack without pausing again
+	for range 2 {
+		conn.WriteJSON(map[string]any{"type": "pause", "id": 1, "request_id": "r1"})
+		if ack := nextFrame(t, conn); ack["type"] != "ack" || ack["request_id"] != "r1" || ack["machine"].(map[string]any)["is_paused"] != true {
+			t.Errorf("got %v, want the paused machine acked", ack)
+		}
+	}
+	conn.WriteJSON(map[string]any{"type": "unpause", "id": 1, "request_id": "r1"})
+	if frame := nextFrame(t, conn); frame["type"] != "error" || frame["request_id"] != "r1" || frame["code"] != "InvalidArgument" {
+		t.Errorf("got %v, want the reused request id refused", frame)
+	}
+
+	conn.WriteJSON(map[string]any{"type": "create", "motion": "dead_reckoning", "speed": 3})
+	if ack := nextFrame(t, conn); ack["command"] != "create" || ack["machine"].(map[string]any)["id"] != float64(100) {
+		t.Errorf("got %v, want the new machine acked", ack)
+	}
+	if got := <-client.commands; got != "pause" || len(client.commands) != 1 {
+		t.Errorf("got %q and %d more, want one pause", got, len(client.commands))
+	}
+	if got := <-client.commands; got != "create DEAD_RECKONING" {
+		t.Errorf("got %q, want a dead reckoning machine created", got)
+	}
+
+	for _, tt := range []struct {
+		command map[string]any
+		code    string
+	}{
+		{map[string]any{"type": "subscribe", "ids": []int{9}}, "NotFound"},
+		{map[string]any{"type": "subscribe", "ids": "some"}, "InvalidArgument"},
+		{map[string]any{"type": "subscribe"}, "InvalidArgument"},
+		{map[string]any{"type": "create", "motion": "teleport"}, "InvalidArgument"},
+		{map[string]any{"type": "launch"}, "InvalidArgument"},
+	} {
+		conn.WriteJSON(tt.command)
+		if frame := nextFrame(t, conn); frame["type"] != "error" || frame["code"] != tt.code {
+			t.Errorf("%v: got %v, want a %s error", tt.command, frame, tt.code)
+		}
+	}
+}
+
+func TestFleetCommandsDoNotHoldUpTelemetry(t *testing.T) {
+	one := &pb.Machine{Id: 1, Location: &pb.GPS{}}
+	client := newFakeClient(one)
+	proxy, url, _ := fleetServer(t, client)
+	proxy.commands.timeout = 500 * time.Millisecond
+	conn, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	nextFrame(t, conn)
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_SNAPSHOT, Machine: one}}}
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": "all"})
+	nextFrame(t, conn)
+	nextFrame(t, conn)
+
+	// Updates keep flowing while the unpause hangs, and commands queued behind it wait their turn
+	conn.WriteJSON(map[string]any{"type": "unpause", "id": 1, "request_id": "u1"})
+	conn.WriteJSON(map[string]any{"type": "pause", "id": 1, "request_id": "p1"})
+	<-client.commands
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: &pb.Machine{Id: 1, FuelLevel: 50}}}}
+	if frame := nextFrame(t, conn); frame["type"] != "telemetry" {
+		t.Fatalf("got %v, want telemetry while the command hangs", frame)
+	}
+	if frame := nextFrame(t, conn); frame["request_id"] != "u1" || frame["code"] != "DeadlineExceeded" {
+		t.Errorf("got %v, want the unpause timed out", frame)
+	}
+	if frame := nextFrame(t, conn); frame["type"] != "ack" || frame["request_id"] != "p1" {
+		t.Errorf("got %v, want the pause acked after it", frame)
+	}
+}
+
+func TestFleetRejectsUnknownProtocolVersion(t *testing.T) {
+	_, resp, err := dialFleet(t, newFakeClient(), "machine-map.v9")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got %v, want the handshake refused", err)
+	}
+}
+
+// sessionFrameOf reads the session frame starting a connection
+func sessionFrameOf(t *testing.T, conn *websocket.Conn) sessionFrame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var frame sessionFrame
+	if err := conn.ReadJSON(&frame); err != nil || frame.Type != "session" {
+		t.Fatalf("got %+v, %v, want the session frame", frame, err)
+	}
+	return frame
+}
+
+// framesAfterResume returns n frames, first those missed while disconnected and then live ones.
+// Updates sent before the reconnect may still be on their way to the session, arriving live
+func framesAfterResume(t *testing.T, conn *websocket.Conn, session sessionFrame, n int) []map[string]any {
+	t.Helper()
+	var frames []map[string]any
+	for _, data := range session.Missed {
+		var frame map[string]any
+		json.Unmarshal(data, &frame)
+		frames = append(frames, frame)
+	}
+	for len(frames) < n {
+		frames = append(frames, nextFrame(t, conn))
+	}
+	return frames
+}
+
+func TestFleetSessionResumes(t *testing.T) {
+	one, two := &pb.Machine{Id: 1, Location: &pb.GPS{}}, &pb.Machine{Id: 2, Location: &pb.GPS{}}
+	client := newFakeClient(one, two)
+	_, url, handled := fleetServer(t, client)
+	conn, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	session := sessionFrameOf(t, conn)
+	if session.Resumed || session.Token == "" {
+		t.Fatalf("got %+v, want a new session", session)
+	}
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_SNAPSHOT, Machine: one}, {Type: pb.FleetEvent_SNAPSHOT, Machine: two}}}
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": []int{1}})
+	nextFrame(t, conn)
+	last := nextFrame(t, conn)
+
+	// Drop the socket and wait for the proxy to notice
+	conn.Close()
+	<-handled
+
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: one}, {Type: pb.FleetEvent_UPDATED, Machine: two}}}
+	client.events <- &pb.Event{Id: 1, MachineId: 1, Detail: &pb.Event_Alert{Alert: &pb.Alert{}}}
+	client.events <- &pb.Event{Id: 2, MachineId: 2, Detail: &pb.Event_Alert{Alert: &pb.Alert{}}}
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: one}}}
+
+	conn, _, err = dial(t, fmt.Sprintf("%s?session=%s&seq=%v", url, session.Token, last["seq"]))
+	if err != nil {
+		t.Fatalf("redial failed: %v", err)
+	}
+	resumed := sessionFrameOf(t, conn)
+	if !resumed.Resumed || resumed.Token != session.Token || resumed.Resynced {
+		t.Fatalf("got %+v, want session %s resumed", resumed, session.Token)
+	}
+	var types []string
+	for _, frame := range framesAfterResume(t, conn, resumed, 3) {
+		types = append(types, frame["type"].(string))
+		if frame["type"] == "telemetry" && !slices.Equal(frameIDs(frame), []float64{1}) {
+			t.Errorf("got %v, want only machine 1", frame)
+		}
+	}
+	if slices.Sort(types); !slices.Equal(types, []string{"event", "telemetry", "telemetry"}) {
+		t.Errorf("got %v, want machine 1's updates and event", types)
+	}
+
+	// The resumed socket takes commands as before
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": "all"})
+	nextFrame(t, conn)
+	if frame := nextFrame(t, conn); !slices.Equal(frameIDs(frame), []float64{1, 2}) {
+		t.Errorf("got %v, want machines 1 and 2", frame)
+	}
+}
+
+// A browser on flaky Wi-Fi often redials before the proxy notices its old socket is dead
+func TestFleetSessionTakenOverBeforeSocketCloses(t *testing.T) {
+	one := &pb.Machine{Id: 1, Location: &pb.GPS{}}
+	client := newFakeClient(one)
+	_, url, _ := fleetServer(t, client)
+	old, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	session := sessionFrameOf(t, old)
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_SNAPSHOT, Machine: one}}}
+	old.WriteJSON(map[string]any{"type": "subscribe", "ids": "all"})
+	nextFrame(t, old)
+	last := nextFrame(t, old)
+
+	// Written to the old socket, but lost on the way to the browser
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: &pb.Machine{Id: 1, FuelLevel: 70}}}}
+	nextFrame(t, old)
+
+	conn, _, err := dial(t, fmt.Sprintf("%s?session=%s&seq=%v", url, session.Token, last["seq"]))
+	if err != nil {
+		t.Fatalf("redial failed: %v", err)
+	}
+	resumed := sessionFrameOf(t, conn)
+	if !resumed.Resumed || resumed.Resynced {
+		t.Fatalf("got %+v, want session %s resumed", resumed, session.Token)
+	}
+	if len(resumed.Missed) != 1 {
+		t.Fatalf("got %d missed frames, want the lost update", len(resumed.Missed))
+	}
+	frame := framesAfterResume(t, conn, resumed, 1)[0]
+	if frame["seq"] != last["seq"].(float64)+1 || frame["machines"].([]any)[0].(map[string]any)["fuel_level"] != float64(70) {
+		t.Errorf("got %v, want the update sent to the old socket", frame)
+	}
+
+	// The old socket is let go
+	old.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := old.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
+
+func TestFleetDropsSilentSocket(t *testing.T) {
+	client := newFakeClient()
+	proxy, url, handled := fleetServer(t, client)
+	proxy.sessions.pongWait = 100 * time.Millisecond
+	conn, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	session := sessionFrameOf(t, conn)
+
+	// Reading answers the pings, so the socket is kept
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-handled:
+		t.Fatalf("socket dropped while answering pings")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// A socket that stops answering, like one that died without closing, is dropped and the session kept
+	silent, _, err := dial(t, url+"?session="+session.Token)
+	if err != nil {
+		t.Fatalf("redial failed: %v", err)
+	}
+	<-handled
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("silent socket kept, want it dropped after pongWait")
+	}
+	if proxy.sessions.get(session.Token) == nil {
+		t.Errorf("session dropped, want it kept for a reconnect")
+	}
+	silent.Close()
+}
+
+func TestFleetSessionResyncsAfterOverflow(t *testing.T) {
+	one := &pb.Machine{Id: 1, Location: &pb.GPS{}}
+	client := newFakeClient(one)
+	_, url, handled := fleetServer(t, client)
+	conn, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	session := sessionFrameOf(t, conn)
+	client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_SNAPSHOT, Machine: one}}}
+	conn.WriteJSON(map[string]any{"type": "subscribe", "ids": "all"})
+	nextFrame(t, conn)
+	nextFrame(t, conn)
+	conn.Close()
+	<-handled
+
+	// Well past the buffer, even with the last few updates still on their way
+	const updates = maxMissedFrames + 64
+	for i := range updates {
+		client.fleet <- &pb.FleetUpdate{Events: []*pb.FleetEvent{{Type: pb.FleetEvent_UPDATED, Machine: &pb.Machine{Id: 1, FuelLevel: float32(i + 1)}}}}
+	}
+
+	conn, _, err = dial(t, url+"?session="+session.Token)
+	if err != nil {
+		t.Fatalf("redial failed: %v", err)
+	}
+	resumed := sessionFrameOf(t, conn)
+	if !resumed.Resumed || !resumed.Resynced || len(resumed.Missed) != 1 {
+		t.Fatalf("got %d missed frames, resynced %v, want one frame of current state", len(resumed.Missed), resumed.Resynced)
+	}
+	var state telemetryFrame
+	json.Unmarshal(resumed.Missed[0], &state)
+	if len(state.Machines) != 1 || state.Machines[0].FuelLevel <= maxMissedFrames {
+		t.Fatalf("got %v, want the latest state of machine 1", state.Machines)
+	}
+	for fuel := state.Machines[0].FuelLevel; fuel < updates; {
+		fuel = float32(nextFrame(t, conn)["machines"].([]any)[0].(map[string]any)["fuel_level"].(float64))
+	}
+}
+
+func TestFleetSessionExpires(t *testing.T) {
+	client := newFakeClient()
+	proxy, url, handled := fleetServer(t, client)
+	proxy.sessions.grace = 10 * time.Millisecond
+	conn, _, err := dial(t, url)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	session := sessionFrameOf(t, conn)
+	client.fleet <- &pb.FleetUpdate{}
+	conn.Close()
+	<-handled
+
+	for deadline := time.Now().Add(5 * time.Second); proxy.sessions.get(session.Token) != nil; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session still kept, want it dropped after the grace window")
+		}
+	}
+	conn, _, err = dial(t, url+"?session="+session.Token)
+	if err != nil {
+		t.Fatalf("redial failed: %v", err)
+	}
+	if fresh := sessionFrameOf(t, conn); fresh.Resumed || fresh.Token == session.Token {
+		t.Errorf("got %+v, want a new session", fresh)
+	}
+}
@@ -4,16 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	pb "stream-machine-map-monitor/proto"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 var upgrader = websocket.Upgrader{
@@ -27,6 +34,10 @@ type ProxyServer struct {
 	// Use client stub for "local" function calls
 	grpcClient pb.MachineMapClient
 	conn *grpc.ClientConn
+	// Outcomes of recent commands, so retries are not applied twice
+	commands *commandLog
+	// /fleet sessions kept for browsers to reconnect to
+	sessions *sessionRegistry
 }
 
 func NewProxyServer() (*ProxyServer, error){
@@ -43,7 +54,7 @@ func NewProxyServer() (*ProxyServer, error){
 	}
 
 	client := pb.NewMachineMapClient(conn)
-	return &ProxyServer{grpcClient: client, conn: conn},nil
+	return &ProxyServer{grpcClient: client, conn: conn, commands: newCommandLog(), sessions: newSessionRegistry()},nil
 	
 }
 
@@ -55,6 +66,95 @@ func (s *ProxyServer) Close() {
 	}
 }
 
+// resolveMachine returns the id of the machine to stream. Without an id a new machine is
+// created, using the motion model named by the "motion" query parameter if given
+func (s *ProxyServer) resolveMachine(ctx context.Context, query url.Values) (uint32, error) {
+	id := query.Get("id")
+	if id == "" {
+		req, err := createMachineRequest(query)
+		if err != nil {
+			return 0, err
+		}
+		machine, err := s.grpcClient.CreateMachine(ctx, req)
+		if err != nil {
+			return 0, err
+		}
+		return machine.Id, nil
+	}
+
+	parsed, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid machine id %q", id)
+	}
+	return uint32(parsed), nil
+}
+
+// createMachineRequest builds a CreateMachineRequest from the motion, speed and heading query parameters
+func createMachineRequest(query url.Values) (*pb.CreateMachineRequest, error) {
+	req := &pb.CreateMachineRequest{}
+
+	if motion := query.Get("motion"); motion != "" {
+		modelType, err := parseMotionModel(motion)
+		if err != nil {
+			return nil, err
+		}
+		req.MotionModel = modelType
+	}
+	for name, field := range map[string]*float64{"speed": &req.Speed, "heading": &req.Heading} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s %q", name, value)
+			}
+			*field = parsed
+		}
+	}
+	return req, nil
+}
+
+// parseMotionModel looks up a motion model by name, e.g. "correlated_random_walk"
+func parseMotionModel(name string) (pb.MotionModelType, error) {
+	modelType, ok := pb.MotionModelType_value[strings.ToUpper(name)]
+	if !ok {
+		return 0, status.Errorf(codes.InvalidArgument, "unknown motion model %q", name)
+	}
+	return pb.MotionModelType(modelType), nil
+}
+
+// errorFrame is sent to the browser in place of a machine when a command or stream fails
+type errorFrame struct {
+	Type      string `json:"type"` // always "error"
+	RequestID string `json:"request_id,omitempty"` // echoed from the failed command
+	Command   string `json:"command,omitempty"`
+	Code      string `json:"code"` // gRPC status code name, e.g. "NotFound"
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message"`
+	ID        uint32 `json:"id,omitempty"`
+}
+
+// newErrorFrame converts a gRPC error into an error frame, pulling the machine id from its details
+func newErrorFrame(command string, err error) errorFrame {
+	st := status.Convert(err)
+	frame := errorFrame{
+		Type:    "error",
+		Command: command,
+		Code:    st.Code().String(),
+		Message: st.Message(),
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		frame.Reason = info.Reason
+		if id, err := strconv.ParseUint(info.Metadata["machine_id"], 10, 32); err == nil {
+			frame.ID = uint32(id)
+		}
+	}
+	return frame
+}
+
 // Handle incoming Websocket connection requests from browser client
 func (s *ProxyServer) handleMachine(w http.ResponseWriter, r *http.Request){
 	// Initialize connection
@@ -65,12 +165,24 @@ func (s *ProxyServer) handleMachine(w http.ResponseWriter, r *http.Request){
 	}
 	defer conn.Close()
 
+	// Every write goes through the writer, gorilla/websocket allows only one writer at a time
+	writer := newConnWriter(conn)
+	defer writer.close()
 
 	// Create gRPC stream
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	stream, err := s.grpcClient.MachineStream(ctx, &pb.MachineStreamRequest{})
+	// Attach to an existing machine when an id is given, otherwise create a new one.
+	// Machines outlive the WebSocket, so other clients can keep watching them
+	machineID, err := s.resolveMachine(ctx, r.URL.Query())
+	if err != nil {
+		log.Printf("Failed to resolve machine: %v", err)
+		writer.sendError("stream", err)
+		return
+	}
+
+	stream, err := s.grpcClient.MachineStream(ctx, &pb.MachineStreamRequest{Id: machineID})
 	if err != nil {
 		log.Printf("Failed to start machine stream: %v", err)
 		return
@@ -83,22 +195,17 @@ func (s *ProxyServer) handleMachine(w http.ResponseWriter, r *http.Request){
 			
 			if err != nil {
 				log.Printf("Stream ended: %v", err)
+				if status.Code(err) != codes.Canceled {
+					writer.sendError("stream", err)
+				}
 				cancel()
 				return
 			}
 
 
-		// Convert to JSON and send to WebSocket
-		machineJSON, err := json.Marshal(machine)
-		// log.Printf("WS Proxy: sending response in handleMachine goroutine before websocket.TextMessage: \n%s",machineJSON)
-
-		if err != nil {
-			log.Printf("Failed to marshal machine: %v", err)
-			continue
-		}
-
-		if err := conn.WriteMessage(websocket.TextMessage, machineJSON); err != nil {
-			log.Printf("Failed to write message: %v", err)
+		// Queue for the WebSocket behind any pending acks. Fails once the writer has given up on the browser
+		if err := writer.send(priorityTelemetry, machine); err != nil {
+			log.Printf("Failed to send machine: %v", err)
 			cancel()
 			return
 		}
@@ -114,36 +221,35 @@ for {
 	}
 
 	var request struct {
-		Type string `json:"type"`
-		ID   uint32 `json:"id"`
+		Type      string  `json:"type"`
+		RequestID string  `json:"request_id"` // echoed in the ack or error, and dedupes retries
+		ID        uint32  `json:"id"`
+		Rate      float32 `json:"rate"` // refuel only, 0 refuels instantly
 	}
 
 	if err := json.Unmarshal(message, &request); err != nil {
 		log.Printf("Failed to unmarshal request: %v", err)
+		writer.sendError("", status.Errorf(codes.InvalidArgument, "malformed request: %v", err))
 		continue
 	}
 
-	var response *pb.Machine
-	switch request.Type {
-	case "pause":
-		response, err = s.grpcClient.Pause(ctx, &pb.Machine{Id: request.ID})
-	case "unpause":
-		response, err = s.grpcClient.UnPause(ctx, &pb.Machine{Id: request.ID})
-	default:
+	apply, err := machineCommand(s.grpcClient, request.Type, request.ID, request.Rate)
+	if err != nil {
 		log.Printf("Unknown request type: %s", request.Type)
+		writer.sendCommandError(request.RequestID, request.Type, err)
 		continue
 	}
 
+	command := fleetCommand{Type: request.Type, ID: request.ID, Rate: request.Rate}
+	response, err := s.commands.run(ctx, request.RequestID, command.key(), apply)
 	if err != nil {
-		log.Printf("Failed to pause/unpause machine: %v", err)
+		log.Printf("Failed to %s machine: %v", request.Type, err)
+		writer.sendCommandError(request.RequestID, request.Type, err)
 		continue
 	}
 
-	// Send confirmation back to client
-	responseJSON, _ := json.Marshal(response)
-	
-	// log.Printf("WS Proxy: sending response to incoming command in handleMachine before websocket.TextMessage: \n%s",responseJSON)
-	if err := conn.WriteMessage(websocket.TextMessage, responseJSON); err != nil {
+	// Send confirmation back to client, ahead of any queued telemetry. The ack frame tells it apart from telemetry
+	if err := writer.send(priorityControl, ackFrame{Type: "ack", RequestID: request.RequestID, Command: request.Type, Machine: response}); err != nil {
 		log.Printf("failed to write response: %v", err)
 		cancel()
 		break
@@ -151,6 +257,20 @@ for {
 }
 log.Println("Client disconnected, cleaning up")
 }
+
+// newServer serves handler on addr. Shutdown waits for handlers to return, so it also cancels the
+// context of every request, ending streams such as /events that would otherwise never return
+func newServer(addr string, handler http.Handler) *http.Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	server.RegisterOnShutdown(cancel)
+	return server
+}
+
 func main() {
 	proxy, err := NewProxyServer()
 	if err != nil {
@@ -158,6 +278,11 @@ func main() {
 	}
 
 	http.HandleFunc("/machine", proxy.handleMachine)
+	http.HandleFunc("/fleet", proxy.handleFleet)
+	// For browsers that cannot open a WebSocket
+	http.HandleFunc("GET /events", proxy.handleEvents)
+	http.HandleFunc("POST /machines", proxy.handleCommand)
+	http.HandleFunc("POST /machines/{id}/{command}", proxy.handleCommand)
 
 	// CORS
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -172,10 +297,7 @@ func main() {
 	})
 
 	// Create server instance
-	server := &http.Server{
-		Addr: ":3001",
-		Handler: nil,
-	}
+	server := newServer(":3001", nil)
 
 	// Create channel to listen for SIGTERM
 	stopChan := make(chan os.Signal, 1)
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"net/url"
+	pb "stream-machine-map-monitor/proto"
 	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	// "github.com/gorilla/websocket"
 	// "context"
 	// "strings"
@@ -61,4 +67,40 @@ func TestWebSocketHandler(t *testing.T) {
 
 func TestPauseUnpauseCommands(t *testing.T) {
 	// placeholder
-}
\ No newline at end of file
+}
+
+func TestNewErrorFrame(t *testing.T) {
+	st, _ := status.New(codes.NotFound, "machine 7 not found").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "MACHINE_NOT_FOUND",
+		Metadata: map[string]string{"machine_id": "7"},
+	})
+
+	frame := newErrorFrame("pause", st.Err())
+	want := errorFrame{
+		Type:    "error",
+		Command: "pause",
+		Code:    "NotFound",
+		Reason:  "MACHINE_NOT_FOUND",
+		Message: "machine 7 not found",
+		ID:      7,
+	}
+	if frame != want {
+		t.Errorf("got %+v, want %+v", frame, want)
+	}
+}
+
+func TestCreateMachineRequest(t *testing.T) {
+	query := url.Values{"motion": {"dead_reckoning"}, "speed": {"4.5"}, "heading": {"90"}}
+
+	req, err := createMachineRequest(query)
+	if err != nil {
+		t.Fatalf("createMachineRequest failed: %v", err)
+	}
+	if req.MotionModel != pb.MotionModelType_DEAD_RECKONING || req.Speed != 4.5 || req.Heading != 90 {
+		t.Errorf("unexpected request: %v", req)
+	}
+
+	if _, err := createMachineRequest(url.Values{"motion": {"teleport"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument for unknown motion model", err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	pb "stream-machine-map-monitor/proto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	sessionGrace    = 30 * time.Second // how long a session waits for its browser to reconnect
+	maxMissedFrames = 256              // recent frames kept for a reconnecting browser, which is resynced from the cache when it missed more
+	pongWait        = 30 * time.Second // how long a socket may go without answering a ping before it is considered gone
+)
+
+// sessionFrame is the first frame on every /fleet socket and /events stream. The token resumes the
+// session after a reconnect, as /fleet?session=<token>&seq=<seq> or with the Last-Event-ID of the
+// stream
+type sessionFrame struct {
+	Type     string            `json:"type"` // always "session"
+	Token    string            `json:"token"`
+	Seq      uint64            `json:"seq"`                // frames sent so far, every later frame carries the next seq
+	Resumed  bool              `json:"resumed"`            // subscriptions were kept, otherwise this is a new session
+	Missed   []json.RawMessage `json:"missed,omitempty"`   // frames sent while disconnected, oldest first
+	Resynced bool              `json:"resynced,omitempty"` // too much was missed, so missed holds the current state and events were lost
+}
+
+// sessionRegistry finds /fleet sessions by token
+type sessionRegistry struct {
+	grace    time.Duration
+	pongWait time.Duration // for the sockets of its sessions
+
+	mu      sync.Mutex
+	byToken map[string]*fleetSession
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{grace: sessionGrace, pongWait: pongWait, byToken: make(map[string]*fleetSession)}
+}
+
+// get returns the live session for token, or nil
+func (r *sessionRegistry) get(token string) *fleetSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byToken[token]
+}
+
+func (r *sessionRegistry) add(session *fleetSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byToken[session.token] = session
+}
+
+func (r *sessionRegistry) remove(session *fleetSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byToken, session.token)
+}
+
+func newSessionToken() string {
+	token := make([]byte, 16)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+// eventID names frame seq of a session, as "<token>.<seq>"
+func eventID(token string, seq uint64) string {
+	return token + "." + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID splits an id from eventID. ok is false for anything else
+func parseEventID(id string) (token string, seq uint64, ok bool) {
+	token, number, found := strings.Cut(id, ".")
+	if !found {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(number, 10, 64)
+	return token, seq, err == nil
+}
+
+// numberedFrame is a frame sent by a session, kept for a browser that reconnects
+type numberedFrame struct {
+	seq  uint64
+	data json.RawMessage
+}
+
+// fleetConn is one socket or event stream, attached to at most one session at a time
+type fleetConn struct {
+	writer   *connWriter
+	messages chan []byte   // closed once the connection is gone. Event streams send no messages
+	detached chan struct{} // closed once the session lets go of the connection
+	seen     *uint64       // last frame the browser says it has, when resuming
+}
+
+// newFleetConn starts reading conn, and pinging it so a socket that died without closing is noticed
+// within pongWait
+func newFleetConn(conn *websocket.Conn, writer *connWriter, pongWait time.Duration) *fleetConn {
+	c := &fleetConn{writer: writer, messages: make(chan []byte), detached: make(chan struct{})}
+	go c.read(conn, pongWait)
+	go c.ping(conn, pongWait/2)
+	return c
+}
+
+func (c *fleetConn) read(conn *websocket.Conn, pongWait time.Duration) {
+	defer close(c.messages)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(pongWait)) })
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("WebSocket read error: %v", err)
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		select {
+		case c.messages <- message:
+		case <-c.detached:
+			return
+		}
+	}
+}
+
+// ping pings the browser every period until the session lets go of c. WriteControl may be called
+// alongside the writer goroutine
+func (c *fleetConn) ping(conn *websocket.Conn, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-c.detached:
+			return
+		}
+	}
+}
+
+// attach hands c to the session. False if the session has already ended
+func (s *fleetSession) attach(c *fleetConn) bool {
+	select {
+	case s.attachments <- c:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// attached starts writing to c, first the session frame with the frames the browser missed. A
+// socket still attached is taken over, as the browser reconnecting means it is gone. Frames handed
+// to a socket may never have arrived, so only the browser knows what it missed. One that does not
+// say gets everything since it last attached
+func (s *fleetSession) attached(c *fleetConn) {
+	if s.conn != nil {
+		log.Printf("Session %s moved to a new connection", s.token)
+		s.detach()
+	}
+
+	seen := s.seen
+	if c.seen != nil {
+		seen = *c.seen
+	}
+	frame := sessionFrame{Type: "session", Token: s.token, Seq: s.seq, Resumed: s.resumable}
+	frame.Missed, frame.Resynced = s.missedSince(seen)
+	if frame.Resynced {
+		if data, err := json.Marshal(s.currentState()); err == nil {
+			frame.Missed = []json.RawMessage{data}
+		}
+	}
+	s.resumable = true
+	s.seen = min(seen, s.seq)
+	clear(s.gone)
+
+	s.conn = c
+	if err := c.writer.sendWithID(priorityControl, eventID(s.token, s.seq), frame); err != nil {
+		log.Printf("Failed to send to client: %v", err)
+		s.detach()
+	}
+}
+
+// missedSince returns the frames sent after seq. resync is true when some are no longer kept
+func (s *fleetSession) missedSince(seq uint64) (missed []json.RawMessage, resync bool) {
+	if seq > s.seq || (len(s.history) > 0 && seq+1 < s.history[0].seq) {
+		return nil, true
+	}
+	for _, frame := range s.history {
+		if frame.seq > seq {
+			missed = append(missed, frame.data)
+		}
+	}
+	return missed, false
+}
+
+// currentState is a telemetry frame of every subscribed machine, and of those removed while the
+// browser was away
+func (s *fleetSession) currentState() telemetryFrame {
+	frame := telemetryFrame{Type: "telemetry", Removed: slices.Sorted(maps.Keys(s.gone))}
+	for _, machine := range slices.SortedFunc(maps.Values(s.machines), func(a, b *pb.Machine) int { return cmp.Compare(a.Id, b.Id) }) {
+		if s.isSubscribed(machine.Id) {
+			frame.Machines = append(frame.Machines, machine)
+		}
+	}
+	return frame
+}
+
+// detach lets go of the connection, keeping the session for a reconnect
+func (s *fleetSession) detach() {
+	close(s.conn.detached)
+	s.conn = nil
+}
+
+// emit numbers a frame and sends it to the browser. Recent frames are kept, so a browser that
+// reconnects gets those it missed
+func (s *fleetSession) emit(p priority, frame any) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("Failed to marshal frame: %v", err)
+		return
+	}
+	s.seq++
+	// Every frame is a JSON object, so seq goes in front of its fields
+	data = append(fmt.Appendf(nil, `{"seq":%d,`, s.seq), data[1:]...)
+	if len(s.history) >= maxMissedFrames {
+		s.history = s.history[1:]
+	}
+	s.history = append(s.history, numberedFrame{seq: s.seq, data: data})
+
+	if s.conn == nil {
+		return
+	}
+	if err := s.conn.writer.sendWithID(p, eventID(s.token, s.seq), json.RawMessage(data)); err != nil {
+		log.Printf("Failed to send to client: %v", err)
+		s.detach()
+	}
+}
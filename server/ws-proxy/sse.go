@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	pb "stream-machine-map-monitor/proto"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sseSink writes frames as server-sent events, numbered by the frame id
+type sseSink struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	cancel context.CancelFunc // ends the request
+}
+
+func (s *sseSink) writeFrame(frame queuedFrame, deadline time.Time) error {
+	s.rc.SetWriteDeadline(deadline)
+	if frame.id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", frame.id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", frame.data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *sseSink) close() {
+	s.cancel()
+}
+
+// handleEvents streams the fleet as server-sent events, for browsers behind proxies that block
+// WebSocket upgrades. Each event carries one /fleet frame, starting with the session frame.
+// ?ids=1,2 picks the machines to follow, all of them by default, and commands are posted to
+// /machines. A browser reconnecting with Last-Event-ID resumes its session, getting what it missed
+func (s *ProxyServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	selector, err := parseSelector(r.URL.Query().Get("ids"))
+	if err != nil {
+		writeCommandError(w, "", "stream", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx holding events back
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Printf("Failed to start event stream: %v", err)
+		return
+	}
+
+	writer := newSinkWriter(&sseSink{w: w, rc: rc, cancel: cancel})
+	defer writer.close()
+	c := &fleetConn{writer: writer, messages: make(chan []byte), detached: make(chan struct{})}
+	go func() {
+		<-ctx.Done()
+		close(c.messages)
+	}()
+
+	token, seen, resuming := parseEventID(r.Header.Get("Last-Event-ID"))
+	if resuming {
+		c.seen = &seen
+	}
+	if err := s.joinSession(token, selector, c); err != nil {
+		writer.sendError("stream", err)
+		return
+	}
+	// The session lets go of c soon after ctx is done, but the server may be shutting down
+	select {
+	case <-c.detached:
+	case <-ctx.Done():
+	}
+}
+
+// parseSelector reads the ids query parameter, a comma separated list of machine ids or "all".
+// Empty means all
+func parseSelector(ids string) (machineSelector, error) {
+	if ids == "" || ids == "all" {
+		return machineSelector{all: true}, nil
+	}
+	var selector machineSelector
+	for _, id := range strings.Split(ids, ",") {
+		parsed, err := strconv.ParseUint(strings.TrimSpace(id), 10, 32)
+		if err != nil {
+			return selector, status.Errorf(codes.InvalidArgument, "invalid machine id %q", id)
+		}
+		selector.ids = append(selector.ids, uint32(parsed))
+	}
+	return selector, nil
+}
+
+// handleCommand runs a command posted to /machines/{id}/{command}, or to /machines to create a
+// machine. The optional JSON body takes the fields of a /fleet command, such as request_id. The
+// answer is the ack or error frame /fleet would send
+func (s *ProxyServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var command fleetCommand
+	if err := json.NewDecoder(r.Body).Decode(&command); err != nil && !errors.Is(err, io.EOF) {
+		writeCommandError(w, "", "", status.Errorf(codes.InvalidArgument, "malformed request: %v", err))
+		return
+	}
+
+	var apply func(context.Context) (*pb.Machine, error)
+	command.Type = r.PathValue("command")
+	if command.Type == "" {
+		command.Type = "create"
+		req, err := command.createRequest()
+		if err != nil {
+			writeCommandError(w, command.RequestID, command.Type, err)
+			return
+		}
+		apply = func(ctx context.Context) (*pb.Machine, error) { return s.grpcClient.CreateMachine(ctx, req) }
+	} else {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+		if err != nil {
+			writeCommandError(w, command.RequestID, command.Type, status.Errorf(codes.InvalidArgument, "invalid machine id %q", r.PathValue("id")))
+			return
+		}
+		if apply, err = machineCommand(s.grpcClient, command.Type, uint32(id), command.Rate); err != nil {
+			writeCommandError(w, command.RequestID, command.Type, err)
+			return
+		}
+	}
+
+	machine, err := s.commands.run(r.Context(), command.RequestID, command.key(), apply)
+	if err != nil {
+		log.Printf("Failed to %s machine: %v", command.Type, err)
+		writeCommandError(w, command.RequestID, command.Type, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ackFrame{Type: "ack", RequestID: command.RequestID, Command: command.Type, Machine: machine})
+}
+
+func writeCommandError(w http.ResponseWriter, requestID, command string, err error) {
+	frame := newErrorFrame(command, err)
+	frame.RequestID = requestID
+	writeJSON(w, httpStatus(status.Code(err)), frame)
+}
+
+func writeJSON(w http.ResponseWriter, code int, frame any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(frame); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// httpStatus maps the gRPC codes the server returns to HTTP status codes
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.FailedPrecondition, codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Canceled:
+		return 499 // client closed request, as nginx logs it
+	}
+	return http.StatusInternalServerError
+}
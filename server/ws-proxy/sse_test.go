@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "stream-machine-map-monitor/proto"
+)
+
+// sseEvent is one server-sent event, decoded
+type sseEvent struct {
+	id    string
+	frame map[string]any
+}
+
+// openEvents starts an event stream, resuming from lastEventID unless empty
+func openEvents(t *testing.T, url, lastEventID string) (events chan sseEvent, stop func()) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("opening event stream: %v", err)
+	}
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got content type %q, want an event stream", resp.Header.Get("Content-Type"))
+	}
+
+	events = make(chan sseEvent, 64)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 1<<20)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.frame)
+			case line == "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	t.Cleanup(func() { resp.Body.Close() })
+	return events, func() { resp.Body.Close() }
+}
+
+func nextEvent(t *testing.T, events chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("event stream ended")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event")
+	}
+	return sseEvent{}
+}
+
+// fuelLevels collects the fuel level of machine 1 from telemetry frames, missed or live, until it
+// reaches want
+func fuelLevels(t *testing.T, events chan sseEvent, session sseEvent, want float64) []float64 {
+	t.Helper()
+	frames, _ := session.frame["missed"].([]any)
+	var levels []float64
+	for {
+		var frame map[string]any
+		if len(frames) > 0 {
+			frame, frames = frames[0].(map[string]any), frames[1:]
+		} else {
+			frame = nextEvent(t, events).frame
+		}
+		machines, _ := frame["machines"].([]any)
+		for _, machine := range machines {
+			level := machine.(map[string]any)["fuel_level"].(float64)
+			levels = append(levels, level)
+			if level == want {
+				return levels
+			}
+		}
+	}
+}
+
+func TestEventsResumeFromLastEventID(t *testing.T) {
+	client := newFakeClient()
+	proxy := &ProxyServer{grpcClient: client, commands: newCommandLog(), sessions: newSessionRegistry()}
+	handled := make(chan struct{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.handleEvents(w, r)
+		handled <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	update := func(fuel float32, machines ...uint32) {
+		var events []*pb.FleetEvent
+		for _, id := range machines {
+			events = append(events, &pb.FleetEvent{Type: pb.FleetEvent_UPDATED, Machine: &pb.Machine{Id: id, FuelLevel: fuel}})
+		}
+		client.fleet <- &pb.FleetUpdate{Events: events}
+	}
+
+	events, stop := openEvents(t, server.URL+"/events?ids=1", "")
+	session := nextEvent(t, events)
+	token, seq, ok := parseEventID(session.id)
+	if session.frame["type"] != "session" || session.frame["resumed"] != false || !ok || seq != 0 || token != session.frame["token"] {
+		t.Fatalf("got %+v, want a new session numbered from 0", session)
+	}
+	update(90, 1, 2)
+	first := nextEvent(t, events)
+	if first.id != eventID(token, 1) || len(frameIDs(first.frame)) != 1 {
+		t.Fatalf("got %+v, want machine 1 only as frame 1", first)
+	}
+	update(80, 1)
+	if second := nextEvent(t, events); second.id != eventID(token, 2) {
+		t.Fatalf("got %+v, want frame 2", second)
+	}
+
+	// Reconnect saying only frame 1 arrived, with an update sent while away
+	stop()
+	<-handled
+	update(70, 1)
+	events, _ = openEvents(t, server.URL+"/events?ids=1", first.id)
+	resumed := nextEvent(t, events)
+	if resumed.frame["resumed"] != true || resumed.frame["token"] != token {
+		t.Fatalf("got %+v, want session %s resumed", resumed.frame, token)
+	}
+	if got := fuelLevels(t, events, resumed, 70); len(got) != 2 || got[0] != 80 {
+		t.Errorf("got fuel levels %v, want frames 2 and 3 replayed", got)
+	}
+
+	// An unknown session starts over
+	events, _ = openEvents(t, server.URL+"/events", "gone.5")
+	if fresh := nextEvent(t, events); fresh.frame["resumed"] != false || fresh.frame["token"] == token {
+		t.Errorf("got %+v, want a new session", fresh.frame)
+	}
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	proxy := &ProxyServer{grpcClient: newFakeClient(), commands: newCommandLog(), sessions: newSessionRegistry()}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	server := newServer("", http.HandlerFunc(proxy.handleEvents))
+	go server.Serve(lis)
+
+	events, _ := openEvents(t, "http://"+lis.Addr().String()+"/events", "")
+	nextEvent(t, events)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("got %v, want shutdown with an event stream open", err)
+	}
+}
+
+func TestCommandEndpoints(t *testing.T) {
+	proxy := &ProxyServer{grpcClient: newFakeClient(&pb.Machine{Id: 1}), commands: newCommandLog()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /machines", proxy.handleCommand)
+	mux.HandleFunc("POST /machines/{id}/{command}", proxy.handleCommand)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	for _, tt := range []struct {
+		path, body string
+		code       int
+		frameType  string
+	}{
+		{"/machines/1/pause", `{"request_id": "r1"}`, http.StatusOK, "ack"},
+		{"/machines", `{"motion": "dead_reckoning"}`, http.StatusOK, "ack"},
+		{"/machines", "", http.StatusOK, "ack"},
+		{"/machines/9/pause", "", http.StatusNotFound, "error"},
+		{"/machines/one/pause", "", http.StatusBadRequest, "error"},
+		{"/machines/1/launch", `{"request_id": "r2"}`, http.StatusBadRequest, "error"},
+		{"/machines", `{"motion": "teleport"}`, http.StatusBadRequest, "error"},
+		{"/machines/1/pause", `{`, http.StatusBadRequest, "error"},
+	} {
+		resp, err := http.Post(server.URL+tt.path, "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("POST %s: %v", tt.path, err)
+		}
+		var frame map[string]any
+		json.NewDecoder(resp.Body).Decode(&frame)
+		resp.Body.Close()
+		if resp.StatusCode != tt.code || frame["type"] != tt.frameType {
+			t.Errorf("POST %s %s: got %d %v, want %d with an %s frame", tt.path, tt.body, resp.StatusCode, frame, tt.code, tt.frameType)
+		}
+		if strings.Contains(tt.body, "request_id") && !strings.Contains(tt.body, `"`+frame["request_id"].(string)+`"`) {
+			t.Errorf("POST %s: got request id %v, want it echoed", tt.path, frame["request_id"])
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait          = 10 * time.Second // how long one frame may take to write before the connection is dropped
+	maxQueuedTelemetry = 64               // telemetry frames buffered for a slow browser before the oldest is dropped
+	maxQueuedEvents    = 256              // fleet events buffered for a slow browser before the oldest is dropped
+	maxQueuedControl   = 64               // acks and errors buffered before the browser is considered gone
+)
+
+// priority orders frames waiting to be written. Control frames always go first
+type priority int
+
+const (
+	priorityTelemetry priority = iota // machine updates, droppable since a newer one follows
+	priorityEvent                     // fleet events, which come in bursts, so they are droppable rather than costing the acks their room
+	priorityControl                   // command acks and errors, never dropped
+)
+
+var errWriterClosed = errors.New("websocket writer closed")
+
+// queuedFrame is a frame waiting to be written. id numbers frames for resuming an event stream
+type queuedFrame struct {
+	id   string
+	data []byte
+}
+
+// frameSink is the connection a connWriter writes to
+type frameSink interface {
+	writeFrame(frame queuedFrame, deadline time.Time) error
+	close() // ends the connection, and with it the handler
+}
+
+// wsSink writes frames as WebSocket text messages
+type wsSink struct {
+	conn *websocket.Conn
+}
+
+func (s wsSink) writeFrame(frame queuedFrame, deadline time.Time) error {
+	s.conn.SetWriteDeadline(deadline)
+	return s.conn.WriteMessage(websocket.TextMessage, frame.data)
+}
+
+func (s wsSink) close() {
+	s.conn.Close()
+}
+
+// connWriter is the only goroutine allowed to write to its connection, as gorilla/websocket
+// supports one concurrent writer and so does an http.ResponseWriter. Frames are queued by
+// priority and written with a deadline. A failed write closes the connection, which also ends the
+// handler's read loop
+type connWriter struct {
+	sink      frameSink
+	writeWait time.Duration
+
+	mu            sync.Mutex
+	control       []queuedFrame
+	events        []queuedFrame
+	telemetry     []queuedFrame
+	dropped       uint64 // telemetry frames discarded because the queue was full
+	droppedEvents uint64 // events discarded the same way
+	closed        bool
+	err           error // why the writer stopped
+
+	wake chan struct{} // signalled when a frame is queued or the writer is closed
+	done chan struct{} // closed once the writer goroutine exits
+}
+
+// newConnWriter starts the writer goroutine for a WebSocket
+func newConnWriter(conn *websocket.Conn) *connWriter {
+	return newSinkWriter(wsSink{conn})
+}
+
+func newSinkWriter(sink frameSink) *connWriter {
+	w := &connWriter{
+		sink:      sink,
+		writeWait: writeWait,
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// send marshals frame to JSON and queues it. Telemetry and events are dropped oldest first when
+// the browser falls behind, control frames are never dropped
+func (w *connWriter) send(p priority, frame any) error {
+	return w.sendWithID(p, "", frame)
+}
+
+// sendWithID is send for a numbered frame
+func (w *connWriter) sendWithID(p priority, id string, frame any) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	queued := queuedFrame{id: id, data: data}
+
+	w.mu.Lock()
+	switch {
+	case w.closed:
+		err = w.err
+	case p == priorityControl && len(w.control) >= maxQueuedControl:
+		err = errors.New("too many unsent acks, the browser is not reading")
+	case p == priorityControl:
+		w.control = append(w.control, queued)
+	case p == priorityEvent:
+		if len(w.events) >= maxQueuedEvents {
+			w.events = w.events[1:]
+			w.droppedEvents++
+		}
+		w.events = append(w.events, queued)
+	default:
+		if len(w.telemetry) >= maxQueuedTelemetry {
+			w.telemetry = w.telemetry[1:]
+			w.dropped++
+		}
+		w.telemetry = append(w.telemetry, queued)
+	}
+	w.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	w.signal()
+	return nil
+}
+
+// sendError relays a failed command or stream to the browser as an error frame
+func (w *connWriter) sendError(command string, err error) {
+	w.sendCommandError("", command, err)
+}
+
+// sendCommandError is sendError for a command carrying a request id, which the frame echoes
+func (w *connWriter) sendCommandError(requestID, command string, err error) {
+	frame := newErrorFrame(command, err)
+	frame.RequestID = requestID
+	if err := w.send(priorityControl, frame); err != nil {
+		log.Printf("failed to write error: %v", err)
+	}
+}
+
+func (w *connWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the frame to write next, control frames first, then events. ok is false once the writer is closed
+// and the control frames queued before that are written
+func (w *connWriter) next() (frame queuedFrame, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case len(w.control) > 0:
+		frame, w.control = w.control[0], w.control[1:]
+	case w.closed:
+		return queuedFrame{}, false
+	case len(w.events) > 0:
+		frame, w.events = w.events[0], w.events[1:]
+	case len(w.telemetry) > 0:
+		frame, w.telemetry = w.telemetry[0], w.telemetry[1:]
+	}
+	return frame, true
+}
+
+func (w *connWriter) run() {
+	defer close(w.done)
+
+	for {
+		frame, ok := w.next()
+		if !ok {
+			return
+		}
+		if frame.data == nil {
+			<-w.wake
+			continue
+		}
+
+		if err := w.sink.writeFrame(frame, time.Now().Add(w.writeWait)); err != nil {
+			log.Printf("Failed to write message: %v", err)
+			w.stop(err)
+			w.sink.close()
+			return
+		}
+	}
+}
+
+// stop refuses further frames, recording why
+func (w *connWriter) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.closed, w.err = true, err
+	}
+	w.telemetry = nil
+	w.events = nil
+	w.control = nil
+}
+
+// close writes any queued control frames, drops queued telemetry and events and waits for the
+// writer to exit
+func (w *connWriter) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed, w.err = true, errWriterClosed
+		w.telemetry = nil
+		w.events = nil
+	}
+	dropped, droppedEvents := w.dropped, w.droppedEvents
+	w.mu.Unlock()
+
+	w.signal()
+	<-w.done
+
+	if dropped > 0 {
+		log.Printf("Dropped %d telemetry frames for a slow client", dropped)
+	}
+	if droppedEvents > 0 {
+		log.Printf("Dropped %d events for a slow client", droppedEvents)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// connPair returns the server and browser ends of a WebSocket
+func connPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+	serverConns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	conn := <-serverConns
+	t.Cleanup(func() { conn.Close() })
+	return conn, client
+}
+
+// pausedWriter builds a writer whose goroutine has not started, so frames can be queued first
+func pausedWriter(conn *websocket.Conn) *connWriter {
+	return &connWriter{sink: wsSink{conn}, writeWait: writeWait, wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+func readFrames(t *testing.T, client *websocket.Conn, n int) []map[string]any {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	frames := make([]map[string]any, n)
+	for i := range frames {
+		if err := client.ReadJSON(&frames[i]); err != nil {
+			t.Fatalf("reading frame %d: %v", i, err)
+		}
+	}
+	return frames
+}
+
+func TestWriterSendsAcksBeforeTelemetry(t *testing.T) {
+	conn, client := connPair(t)
+	w := pausedWriter(conn)
+	for i := range 3 {
+		w.send(priorityTelemetry, map[string]int{"telemetry": i})
+	}
+	w.send(priorityControl, map[string]string{"ack": "pause"})
+	go w.run()
+	defer w.close()
+
+	frames := readFrames(t, client, 4)
+	if frames[0]["ack"] != "pause" {
+		t.Errorf("got %v first, want the ack", frames[0])
+	}
+	for i, frame := range frames[1:] {
+		if frame["telemetry"] != float64(i) {
+			t.Errorf("got %v, want telemetry %d in order", frame, i)
+		}
+	}
+}
+
+func TestWriterDropsOldestTelemetry(t *testing.T) {
+	conn, client := connPair(t)
+	w := pausedWriter(conn)
+	for i := range maxQueuedTelemetry + 10 {
+		w.send(priorityTelemetry, map[string]int{"telemetry": i})
+	}
+	go w.run()
+	defer w.close()
+
+	frames := readFrames(t, client, maxQueuedTelemetry)
+	if frames[0]["telemetry"] != float64(10) || w.dropped != 10 {
+		t.Errorf("got %v first with %d dropped, want the 10 oldest dropped", frames[0], w.dropped)
+	}
+}
+
+func TestWriterKeepsAcksThroughEventBurst(t *testing.T) {
+	conn, client := connPair(t)
+	w := pausedWriter(conn)
+
+	// A browser not reading while events flood in still gets its ack, and the latest events
+	const events = maxQueuedEvents + maxQueuedControl*2
+	for i := range events {
+		if err := w.send(priorityEvent, map[string]int{"event": i}); err != nil {
+			t.Fatalf("event %d: %v, want the burst absorbed", i, err)
+		}
+	}
+	if err := w.send(priorityControl, map[string]string{"ack": "pause"}); err != nil {
+		t.Fatalf("ack after the burst: %v", err)
+	}
+	go w.run()
+	defer w.close()
+
+	frames := readFrames(t, client, maxQueuedEvents+1)
+	if frames[0]["ack"] != "pause" || frames[1]["event"] != float64(events-maxQueuedEvents) || frames[maxQueuedEvents]["event"] != float64(events-1) {
+		t.Errorf("got %v, %v first, want the ack and then the newest %d events", frames[0], frames[1], maxQueuedEvents)
+	}
+}
+
+func TestWriterGivesUpOnStalledBrowser(t *testing.T) {
+	conn, _ := connPair(t)
+	w := pausedWriter(conn)
+	w.writeWait = 50 * time.Millisecond
+	go w.run()
+
+	// The browser never reads, so writes block once the socket buffers fill and then time out
+	payload := strings.Repeat("x", 64*1024)
+	deadline := time.After(5 * time.Second)
+	for {
+		if err := w.send(priorityTelemetry, map[string]string{"payload": payload}); err != nil {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("writer still accepting frames, want it stopped by the write deadline")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	w.close()
+
+	if err := w.send(priorityControl, map[string]string{"ack": "pause"}); err == nil {
+		t.Errorf("send succeeded after the writer stopped")
+	}
+}
+
+func TestWriterCloseFlushesAcks(t *testing.T) {
+	conn, client := connPair(t)
+	w := newConnWriter(conn)
+	w.send(priorityControl, newErrorFrame("stream", errWriterClosed))
+	w.close()
+
+	var frame errorFrame
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := client.ReadMessage()
+	if err != nil || json.Unmarshal(data, &frame) != nil || frame.Type != "error" {
+		t.Errorf("got %s, %v, want the queued error frame", data, err)
+	}
+}